@@ -3,3 +3,5 @@ module github.com/SQUASHD/gogi
 go 1.21
 
 require github.com/SQUASHD/go-config v0.0.0-20231106124336-9fc91adabc57
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,91 @@
+package collection
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Entry is a template found in a local clone of the github/gitignore
+// collection
+type Entry struct {
+	// Name is the template name derived from the file name
+	Name string
+	// Category is "" for the top level templates, and "global" or
+	// "community" for the templates of those subtrees
+	Category string
+	// Path is the path of the template file
+	Path string
+}
+
+// PrefixedName returns the name of the entry prefixed with its category
+func (e Entry) PrefixedName() string {
+	if e.Category == "" {
+		return e.Name
+	}
+	return e.Category + "-" + e.Name
+}
+
+// Scan walks the collection at dir and returns every *.gitignore file in
+// it. Top level templates come first, followed by the Global and
+// community subtrees, each sorted by path.
+func Scan(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && p != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".gitignore") || d.Name() == ".gitignore" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{
+			Name:     TemplateName(d.Name()),
+			Category: category(filepath.ToSlash(rel)),
+			Path:     p,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read collection at %s: %w", dir, err)
+	}
+
+	order := map[string]int{"": 0, "global": 1, "community": 2}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Category != entries[j].Category {
+			return order[entries[i].Category] < order[entries[j].Category]
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// TemplateName turns a collection file name such as "Visual Studio.gitignore"
+// into a gogi template name such as "visual-studio"
+func TemplateName(fileName string) string {
+	name := strings.TrimSuffix(fileName, ".gitignore")
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// category returns the category of a template from its path relative to
+// the root of the collection
+func category(rel string) string {
+	top := strings.SplitN(rel, "/", 2)[0]
+	if top == rel {
+		return ""
+	}
+	return strings.ToLower(top)
+}
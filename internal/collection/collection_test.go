@@ -0,0 +1,73 @@
+package collection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCollection(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := writeCollection(t,
+		"community/Golang/Hugo.gitignore",
+		"Global/macOS.gitignore",
+		"Go.gitignore",
+		"VisualStudio.gitignore",
+		"README.md",
+		".github/workflow.gitignore",
+	)
+
+	entries, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	expected := []string{"go", "visualstudio", "global-macos", "community-hugo"}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries but got %d: %v", len(expected), len(entries), entries)
+	}
+	for i, entry := range entries {
+		if entry.PrefixedName() != expected[i] {
+			t.Errorf("Expected entry %d to be %s but got %s", i, expected[i], entry.PrefixedName())
+		}
+	}
+}
+
+func TestTemplateName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		expected string
+	}{
+		{"Go.gitignore", "go"},
+		{"macOS.gitignore", "macos"},
+		{"Visual Studio Code.gitignore", "visual-studio-code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			if got := TemplateName(tt.fileName); got != tt.expected {
+				t.Errorf("TemplateName(%q) = %q, want %q", tt.fileName, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanMissingDir(t *testing.T) {
+	if _, err := Scan(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Expected an error for a missing collection")
+	}
+}
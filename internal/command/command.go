@@ -1,9 +1,14 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/generator"
 	"github.com/SQUASHD/gogi/internal/structs"
 )
 
@@ -21,8 +26,10 @@ var aliasMap = map[string]string{
 	"e": "edit",
 	"d": "delete",
 	"a": "append",
+	"i": "import",
 	"b": "base",
 	"r": "rename",
+	"t": "test",
 }
 
 // Context holds the state and provides methods to execute CLI commands
@@ -61,7 +68,7 @@ func (ctx *Context) getCommands() map[string]cliCommand {
 		"create": {
 			name:        "create",
 			description: "Create a new template",
-			helpExample: "gogi create template-name [-e | --edit] [-b | --base]",
+			helpExample: "gogi create template-name [-e | --edit] [-b | --base] [--from file | -] [-f | --force]",
 			callback:    (*Context).commandCreate,
 		},
 		"delete": {
@@ -79,7 +86,7 @@ func (ctx *Context) getCommands() map[string]cliCommand {
 		"generate": {
 			name:        "generate",
 			description: "Generate a gitignore file from the given template",
-			helpExample: "gogi generate template-name [--f | --force]",
+			helpExample: "gogi generate template-name [template-name...] [-f | --force] [-m | --merge] [--set key=value] [--os name] [--header | --no-header] [--lock] [--update] [--record]",
 			callback:    (*Context).commandGenerate,
 		},
 		"edit": {
@@ -91,9 +98,129 @@ func (ctx *Context) getCommands() map[string]cliCommand {
 		"append": {
 			name:        "append",
 			description: "Append a template to an existing gitignore file",
-			helpExample: "gogi append template-name",
+			helpExample: "gogi append template-name [template-name...] [-m | --merge] [--set key=value]",
 			callback:    (*Context).commandAppend,
 		},
+		"remove": {
+			name:        "remove",
+			description: "Remove the lines a template added to the gitignore file",
+			helpExample: "gogi remove template-name [-f | --force]",
+			callback:    (*Context).commandRemove,
+		},
+		"test": {
+			name:        "test",
+			description: "Check which paths a template ignores",
+			helpExample: "gogi test template-name path... [--expect-ignored | --expect-not-ignored] [--os name] [--set key=value]",
+			callback:    (*Context).commandTest,
+		},
+		"why": {
+			name:        "why",
+			description: "Explain which rule and template ignore a path",
+			helpExample: "gogi why path [--os name] [--set key=value]",
+			callback:    (*Context).commandWhy,
+		},
+		"lint": {
+			name:        "lint",
+			description: "Check templates or the project gitignore file for mistakes",
+			helpExample: "gogi lint [template-name... | -p | --project] [--fix]",
+			callback:    (*Context).commandLint,
+		},
+		"import": {
+			name:        "import",
+			description: "Create a template from an existing gitignore file",
+			helpExample: "gogi import template-name [path-to-file | --url url] [--offline] [-f | --force]",
+			callback:    (*Context).commandImport,
+		},
+		"import-collection": {
+			name:        "import-collection",
+			description: "Import every template of a local clone of github/gitignore",
+			helpExample: "gogi import-collection dir [--only name,name] [--prefix] [-f | --force]",
+			callback:    (*Context).commandImportCollection,
+		},
+		"repo": {
+			name:        "repo",
+			description: "Sync the template directory with a git remote",
+			helpExample: "gogi repo [remote-url] [--branch name]",
+			callback:    (*Context).commandRepo,
+		},
+		"push": {
+			name:        "push",
+			description: "Commit template changes and push them to the git remote",
+			helpExample: "gogi push",
+			callback:    (*Context).commandPush,
+		},
+		"pull": {
+			name:        "pull",
+			description: "Fast-forward the templates to the git remote",
+			helpExample: "gogi pull",
+			callback:    (*Context).commandPull,
+		},
+		"serve": {
+			name:        "serve",
+			description: "Serve a template registry over HTTP",
+			helpExample: "gogi serve [--addr host:port] [--dir path]",
+			callback:    (*Context).commandServe,
+		},
+		"remote": {
+			name:        "remote",
+			description: "Manage the template registries to install from",
+			helpExample: "gogi remote [add name url | list | remove name]",
+			callback:    (*Context).commandRemote,
+		},
+		"install": {
+			name:        "install",
+			description: "Install a template from a remote registry",
+			helpExample: "gogi install remote/template-name [--as name] [-f | --force]",
+			callback:    (*Context).commandInstall,
+		},
+		"detect": {
+			name:        "detect",
+			description: "Suggest templates for the project in the current directory",
+			helpExample: "gogi detect [--auto] [-f | --force] [-m | --merge]",
+			callback:    (*Context).commandDetect,
+		},
+		"vars": {
+			name:        "vars",
+			description: "List the variables of a template or set their defaults",
+			helpExample: "gogi vars template-name [key=value...]",
+			callback:    (*Context).commandVars,
+		},
+		"bundle": {
+			name:        "bundle",
+			description: "Manage named lists of templates used together",
+			helpExample: "gogi bundle [create bundle-name template-name... [-f | --force] | list | show bundle-name | delete bundle-name]",
+			callback:    (*Context).commandBundle,
+		},
+		"status": {
+			name:        "status",
+			description: "Check whether the gitignore file drifted from its templates",
+			helpExample: "gogi status [--set key=value] [--os name]",
+			callback:    (*Context).commandStatus,
+		},
+		"diff": {
+			name:        "diff",
+			description: "Show how generating from a template would change the gitignore file",
+			helpExample: "gogi diff [template-name...] [-s | --semantic] [--set key=value] [--os name]",
+			callback:    (*Context).commandDiff,
+		},
+		"sync": {
+			name:        "sync",
+			description: "Rebuild the gitignore file from the project manifest .gogi.yaml",
+			helpExample: "gogi sync [--check] [--set key=value] [--os name]",
+			callback:    (*Context).commandSync,
+		},
+		"update": {
+			name:        "update",
+			description: "Merge template changes into the gitignore file, keeping local edits",
+			helpExample: "gogi update [-n | --dry-run] [--set key=value] [--os name]",
+			callback:    (*Context).commandUpdate,
+		},
+		"verify": {
+			name:        "verify",
+			description: "Check the templates against the project lockfile .gogi.lock",
+			helpExample: "gogi verify",
+			callback:    (*Context).commandVerify,
+		},
 		"help": {
 			name:        "help",
 			description: "Display help message, or help for a specific command",
@@ -109,7 +236,7 @@ func (ctx *Context) getCommands() map[string]cliCommand {
 		"base": {
 			name:        "base",
 			description: "set the base template that you call with gogi with no args",
-			helpExample: "gogi base template-name",
+			helpExample: "gogi base [template-name [--dir path-or-glob] | --remove-dir path-or-glob | --explain]",
 			callback:    (*Context).commandBase,
 		},
 		"alias": {
@@ -149,15 +276,59 @@ func (ctx *Context) HandleCommand(args []string) {
 		return
 	}
 
+	if args[0] == "--auto" {
+		args = append([]string{"detect"}, args...)
+	}
+
 	cmdName := resolveCommand(args[0])
 	if cmd, ok := ctx.commands[cmdName]; ok {
-		if err := cmd.callback(ctx, args[1:]); err != nil {
-			fmt.Printf("%v\n", err)
-			os.Exit(1)
+		exitOnError(cmd.callback(ctx, args[1:]))
+		return
+	}
+	unknown := ctx.unknownQuickArg(args)
+	switch {
+	case unknown == "":
+		exitOnError(ctx.HandleQuickGogi(args...))
+	case strings.HasPrefix(unknown, "-"):
+		exitOnError(fmt.Errorf("unknown flag: %s. try gogi help", unknown))
+	default:
+		exitOnError(fmt.Errorf("unknown command or template: %s. try gogi help", unknown))
+	}
+}
+
+// exitError ends a command with the given exit code rather than 1,
+// printing err when there is one
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitOnError prints err and exits with its exit code, which is 1 unless
+// err is an exitError
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		if exitErr.err != nil {
+			fmt.Printf("%v\n", exitErr.err)
 		}
-	} else {
-		fmt.Printf("Unknown command: %s\n", cmdName)
+		os.Exit(exitErr.code)
 	}
+	fmt.Printf("%v\n", err)
+	os.Exit(1)
 }
 
 // Helper function to resolve command aliases
@@ -167,3 +338,103 @@ func resolveCommand(name string) string {
 	}
 	return name
 }
+
+// quickValueFlags are the flags of quick gogi that take a value
+var quickValueFlags = []string{"--set", "--os", "--arch"}
+
+// quickFlags are the flags of quick gogi that take no value
+var quickFlags = []string{"--merge", "-m", "--header", "--no-header", "--lock", "--update", "--record"}
+
+// unknownQuickArg returns the first argument that is neither a template
+// or bundle name nor a flag of quick gogi, or "" when there is none, so
+// that gogi [template-name...] [flags] can be used as a shorthand for
+// quick gogi
+func (ctx *Context) unknownQuickArg(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case hasFlag(args[i:i+1], quickValueFlags...):
+			i++
+		case hasFlag(args[i:i+1], quickFlags...):
+		default:
+			if _, err := ctx.findTemplateOrBundle(args[i]); err != nil {
+				return args[i]
+			}
+		}
+	}
+	return ""
+}
+
+// findTemplates looks up every named template in the configuration and
+// checks that its file exists, keeping the order of names. A bundle name
+// stands for the templates of the bundle, and a template named more than
+// once is only used the first time.
+func (ctx *Context) findTemplates(names []string) ([]structs.Template, error) {
+	templates := make([]structs.Template, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		templNames := []string{name}
+		if _, err := config.FindTemplateByName(ctx.cfg, name); err != nil {
+			if bundle, err := config.FindBundleByName(ctx.cfg, name); err == nil {
+				templNames = bundle.Templates
+			}
+		}
+		for _, templName := range templNames {
+			templ, err := config.FindTemplateByName(ctx.cfg, templName)
+			if err != nil {
+				return nil, fmt.Errorf("could not find template '%s'", templName)
+			}
+			if seen[templ.Name] {
+				continue
+			}
+			seen[templ.Name] = true
+			if err := generator.CheckWhetherTemplateExists(templ.Path); err != nil {
+				return nil, err
+			}
+			templates = append(templates, *templ)
+		}
+	}
+	return templates, nil
+}
+
+// findTemplateOrBundle returns the stored name of the named template or
+// bundle
+func (ctx *Context) findTemplateOrBundle(name string) (string, error) {
+	if templ, err := config.FindTemplateByName(ctx.cfg, name); err == nil {
+		return templ.Name, nil
+	}
+	if bundle, err := config.FindBundleByName(ctx.cfg, name); err == nil {
+		return bundle.Name, nil
+	}
+	return "", fmt.Errorf("no template or bundle with that name exists")
+}
+
+// loadTemplate reads the named template for the templates that include
+// or extend it
+func (ctx *Context) loadTemplate(name string) (structs.Template, []byte, error) {
+	templ, err := config.FindTemplateByName(ctx.cfg, name)
+	if err != nil {
+		return structs.Template{}, nil, err
+	}
+	content, err := os.ReadFile(templ.Path)
+	if err != nil {
+		return structs.Template{}, nil, fmt.Errorf("unable to open template file: %w", err)
+	}
+	return *templ, content, nil
+}
+
+// resolvePath returns p as an absolute path, taking a relative path from
+// the working directory
+func (ctx *Context) resolvePath(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(ctx.cwd, p)
+}
+
+// printMergeResult reports the duplicate lines left out in merge mode
+func printMergeResult(opts generator.Options, result generator.Result) {
+	if !opts.Merge {
+		return
+	}
+	fmt.Printf("skipped %d duplicate line(s)\n", result.Skipped)
+}
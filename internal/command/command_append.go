@@ -2,21 +2,33 @@ package command
 
 import (
 	"fmt"
-	"github.com/SQUASHD/gogi/internal/config"
 	"github.com/SQUASHD/gogi/internal/generator"
+	"strings"
 )
 
 // commandAppend is the callback for the "append" command
-// It appends a template to an existing gitignore file
+// It appends one or more templates to an existing gitignore file
 func (ctx *Context) commandAppend(args []string) error {
-	if len(args) == 0 {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	names, flags := splitArgs(args)
+	if len(names) == 0 {
 		return fmt.Errorf("no template name provided to append")
 	}
 
-	name := args[0]
-	templ, err := config.FindTemplateByName(ctx.cfg, name)
+	templates, err := ctx.findTemplates(names)
 	if err != nil {
-		return fmt.Errorf("could not find template '%s'", name)
+		return err
+	}
+	locked, err := openLock(ctx.cwd, flags)
+	if err != nil {
+		return err
+	}
+	opts, err = locked.guard(templates, opts)
+	if err != nil {
+		return err
 	}
 
 	exists, err := generator.DoesGitignoreExist(ctx.cwd)
@@ -28,10 +40,15 @@ func (ctx *Context) commandAppend(args []string) error {
 		return fmt.Errorf("couldn't find a gitignore file to append to.")
 	}
 
-	if err := generator.AppendTemplate(ctx.cwd, templ.Path); err != nil {
+	result, err := generator.AppendTemplate(ctx.cwd, opts, templates...)
+	if err != nil {
+		return err
+	}
+	if err := locked.save(false); err != nil {
 		return err
 	}
 
-	fmt.Printf("appended template '%s' to gitignore file\n", name)
+	fmt.Printf("appended template '%s' to gitignore file\n", strings.Join(names, "', '"))
+	printMergeResult(opts, result)
 	return nil
 }
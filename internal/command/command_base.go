@@ -3,15 +3,39 @@ package command
 import (
 	"fmt"
 	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"strings"
 )
 
-// commandBase handles setting the base template or callsback the edit
-// command to edit the base template based on the user flags
+// commandBase handles showing and setting the base template, either the
+// global one or a per-directory rule, based on the user flags
 func (ctx *Context) commandBase(args []string) error {
-	baseName := ctx.cfg.Base
-	if len(args) == 0 && baseName == "" {
-		return fmt.Errorf("no base template set")
-	} else if len(args) == 0 {
+	dir, args, hasDir, err := takeFlagValue(args, "--dir")
+	if err != nil {
+		return err
+	}
+	removeDir, args, hasRemoveDir, err := takeFlagValue(args, "--remove-dir")
+	if err != nil {
+		return err
+	}
+	args, flags := splitArgs(args)
+
+	switch {
+	case hasFlag(flags, "--explain"):
+		return ctx.explainBase()
+	case hasRemoveDir:
+		return ctx.removeBaseRule(removeDir)
+	}
+
+	if len(args) == 0 {
+		baseName, ruleIndex := config.ResolveBase(ctx.cfg, ctx.cwd)
+		if baseName == "" {
+			return fmt.Errorf("no base template set")
+		}
+		if ruleIndex >= 0 {
+			fmt.Printf("Your current base file is template: %v (from the rule for '%s')\n", baseName, ctx.cfg.BaseRules[ruleIndex].Path)
+			return nil
+		}
 		fmt.Printf("Your current base file is template: %v\n", baseName)
 		return nil
 	}
@@ -20,14 +44,105 @@ func (ctx *Context) commandBase(args []string) error {
 	if name == "" {
 		return fmt.Errorf("no template name provided")
 	}
-	templ, err := config.FindTemplateByName(ctx.cfg, name)
+	name, err = ctx.findTemplateOrBundle(name)
 	if err != nil {
-		return fmt.Errorf("no template with that name exists")
+		return err
+	}
+	if hasDir {
+		return ctx.setBaseRule(dir, name)
 	}
-	ctx.cfg.Base = templ.Name
+	ctx.cfg.Base = name
 	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
 		return err
 	}
 	fmt.Printf("base template set to '%s'\n", name)
 	return nil
 }
+
+// setBaseRule sets the base template for the directories matching path,
+// replacing the template of an existing rule for the same path so that
+// the order of the rules is kept
+func (ctx *Context) setBaseRule(path, name string) error {
+	if path == "" {
+		return fmt.Errorf("no directory provided")
+	}
+	replaced := false
+	for i, rule := range ctx.cfg.BaseRules {
+		if rule.Path == path {
+			ctx.cfg.BaseRules[i].Template = name
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		ctx.cfg.BaseRules = append(ctx.cfg.BaseRules, structs.BaseRule{Path: path, Template: name})
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return err
+	}
+	fmt.Printf("base template for '%s' set to '%s'\n", path, name)
+	return nil
+}
+
+// removeBaseRule removes the base rule for path
+func (ctx *Context) removeBaseRule(path string) error {
+	for i, rule := range ctx.cfg.BaseRules {
+		if rule.Path != path {
+			continue
+		}
+		ctx.cfg.BaseRules = append(ctx.cfg.BaseRules[:i], ctx.cfg.BaseRules[i+1:]...)
+		if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+			return err
+		}
+		fmt.Printf("base rule for '%s' removed\n", path)
+		return nil
+	}
+	return fmt.Errorf("no base rule for '%s'", path)
+}
+
+// removeFromBaseRules removes the base rules using a deleted template
+func (ctx *Context) removeFromBaseRules(name string) {
+	kept := ctx.cfg.BaseRules[:0]
+	for _, rule := range ctx.cfg.BaseRules {
+		if strings.EqualFold(rule.Template, name) {
+			fmt.Printf("base rule for '%s' removed\n", rule.Path)
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	ctx.cfg.BaseRules = kept
+}
+
+// renameInBaseRules updates the base rules using a renamed template
+func (ctx *Context) renameInBaseRules(oldName, newName string) {
+	for i, rule := range ctx.cfg.BaseRules {
+		if strings.EqualFold(rule.Template, oldName) {
+			ctx.cfg.BaseRules[i].Template = newName
+		}
+	}
+}
+
+// explainBase lists the base rules in order, showing which of them match
+// the current directory and which one decides the base template
+func (ctx *Context) explainBase() error {
+	baseName, winner := config.ResolveBase(ctx.cfg, ctx.cwd)
+	fmt.Printf("Directory: %s\n", ctx.cwd)
+	for i, rule := range ctx.cfg.BaseRules {
+		status := "no match"
+		if i == winner {
+			status = "match, used"
+		} else if config.MatchBaseRule(rule, ctx.cwd) {
+			status = "match, shadowed by an earlier rule"
+		}
+		fmt.Printf("  %d. %s -> %s (%s)\n", i+1, rule.Path, rule.Template, status)
+	}
+	switch {
+	case winner >= 0:
+		fmt.Printf("Base template '%s' comes from rule %d for '%s'\n", baseName, winner+1, ctx.cfg.BaseRules[winner].Path)
+	case baseName != "":
+		fmt.Printf("No rule matches, using the global base template '%s'\n", baseName)
+	default:
+		fmt.Println("No rule matches and no global base template is set")
+	}
+	return nil
+}
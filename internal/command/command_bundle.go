@@ -0,0 +1,135 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"strings"
+)
+
+// commandBundle is the callback for the "bundle" command
+// It creates, lists, shows and deletes named lists of templates
+func (ctx *Context) commandBundle(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return ctx.listBundles()
+	}
+
+	names, flags := splitArgs(args[1:])
+	switch args[0] {
+	case "create":
+		if len(names) < 2 {
+			return fmt.Errorf("expected a bundle name and at least one template")
+		}
+		return ctx.createBundle(names[0], names[1:], hasFlag(flags, "--force", "-f"))
+	case "show":
+		if len(names) != 1 {
+			return fmt.Errorf("expected a bundle name")
+		}
+		bundle, err := config.FindBundleByName(ctx.cfg, names[0])
+		if err != nil {
+			return fmt.Errorf("could not find bundle '%s'", names[0])
+		}
+		fmt.Printf("Bundle '%s':\n", bundle.Name)
+		for i, name := range bundle.Templates {
+			fmt.Printf("%d. %s\n", i+1, name)
+		}
+	case "delete":
+		if len(names) != 1 {
+			return fmt.Errorf("expected a bundle name")
+		}
+		i, err := config.GetBundleIndexByName(ctx.cfg, names[0])
+		if err != nil {
+			return fmt.Errorf("could not find bundle '%s'", names[0])
+		}
+		name := ctx.cfg.Bundles[i].Name
+		ctx.cfg.Bundles = append(ctx.cfg.Bundles[:i], ctx.cfg.Bundles[i+1:]...)
+		if ctx.cfg.Base == name {
+			ctx.cfg.Base = ""
+			fmt.Println("base template deleted")
+		}
+		ctx.removeFromBaseRules(name)
+		if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+			return fmt.Errorf("could not save updated configuration: %w", err)
+		}
+		fmt.Printf("bundle '%s' deleted\n", name)
+	default:
+		return fmt.Errorf("unknown bundle command '%s', expected create, list, show or delete", args[0])
+	}
+	return nil
+}
+
+// createBundle stores a bundle of the given templates, replacing an
+// existing bundle of the same name only when forced
+func (ctx *Context) createBundle(name string, templateNames []string, force bool) error {
+	name = strings.ToLower(name)
+	if err := checkIfReservedWord(name); err != nil {
+		return err
+	}
+	if _, err := config.FindTemplateByName(ctx.cfg, name); err == nil {
+		return fmt.Errorf("a template named '%s' already exists", name)
+	}
+
+	bundle := structs.Bundle{Name: name}
+	for _, templName := range templateNames {
+		templ, err := config.FindTemplateByName(ctx.cfg, templName)
+		if err != nil {
+			return fmt.Errorf("could not find template '%s'", templName)
+		}
+		bundle.Templates = append(bundle.Templates, templ.Name)
+	}
+
+	if i, err := config.GetBundleIndexByName(ctx.cfg, name); err == nil {
+		if !force {
+			return fmt.Errorf("bundle '%s' already exists, use --force to replace it", name)
+		}
+		ctx.cfg.Bundles[i] = bundle
+	} else {
+		ctx.cfg.Bundles = append(ctx.cfg.Bundles, bundle)
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+	fmt.Printf("bundle '%s' created with templates '%s'\n", name, strings.Join(bundle.Templates, "', '"))
+	return nil
+}
+
+// listBundles prints every bundle with its templates
+func (ctx *Context) listBundles() error {
+	if len(ctx.cfg.Bundles) == 0 {
+		fmt.Println("you don't have any bundles!")
+		fmt.Println("try gogi bundle create bundle-name template-name... to create one")
+		return nil
+	}
+	fmt.Println("Available bundles:")
+	for _, bundle := range ctx.cfg.Bundles {
+		fmt.Printf("- %s: %s\n", bundle.Name, strings.Join(bundle.Templates, ", "))
+	}
+	return nil
+}
+
+// removeFromBundles takes a deleted template out of every bundle
+func (ctx *Context) removeFromBundles(name string) {
+	for i, bundle := range ctx.cfg.Bundles {
+		kept := bundle.Templates[:0]
+		for _, templName := range bundle.Templates {
+			if templName != name {
+				kept = append(kept, templName)
+			}
+		}
+		if len(kept) != len(bundle.Templates) {
+			fmt.Printf("template '%s' removed from bundle '%s'\n", name, bundle.Name)
+		}
+		ctx.cfg.Bundles[i].Templates = kept
+	}
+}
+
+// renameInBundles updates the bundles using a renamed template
+func (ctx *Context) renameInBundles(oldName, newName string) {
+	for _, bundle := range ctx.cfg.Bundles {
+		for i, templName := range bundle.Templates {
+			if templName == oldName {
+				bundle.Templates[i] = newName
+			}
+		}
+	}
+}
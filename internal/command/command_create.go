@@ -1,11 +1,11 @@
 package command
 
 import (
-	"errors"
 	"fmt"
 	"github.com/SQUASHD/gogi/internal/config"
 	"github.com/SQUASHD/gogi/internal/generator"
 	"github.com/SQUASHD/gogi/internal/structs"
+	"strings"
 )
 
 const (
@@ -18,63 +18,107 @@ func (ctx *Context) commandCreate(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf(missingTemplate)
 	}
+	args[0] = strings.ToLower(args[0])
 
 	err := checkIfReservedWord(args[0])
 	if err != nil {
 		return err
 	}
 
-	err = ctx.handleCreate(args)
-	if err != nil {
-		return err
-	}
-
-	for _, arg := range args[1:] {
-		switch arg {
+	var content []byte
+	var edit, base, force bool
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
 		case "-e", "-edit":
-			err = ctx.commandEdit(args)
-			if err != nil {
-				return err
-			}
+			edit = true
 		case "-b", "-base":
-			ctx.cfg.Base = args[0]
-			if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
-				return fmt.Errorf("could not save updated configuration: %w", err)
+			base = true
+		case "-f", "--force":
+			force = true
+		case "--from":
+			if i+1 == len(args) {
+				return fmt.Errorf("--from requires a file path or '-' for stdin")
+			}
+			i++
+			if content, err = ctx.readSource(args[i]); err != nil {
+				return err
 			}
-			fmt.Printf("base template set to '%s'\n", args[0])
 		default:
-			return fmt.Errorf("invalid flag '%s', expected -e, -edit, -b, or -base", arg)
+			return fmt.Errorf("invalid flag '%s', expected -e, -edit, -b, -base, --from or --force", args[i])
+		}
+	}
+
+	err = ctx.handleCreate(args[0], content, force)
+	if err != nil {
+		return err
+	}
+
+	if edit {
+		if err = ctx.commandEdit(args); err != nil {
+			return err
 		}
 	}
+	if base {
+		ctx.cfg.Base = args[0]
+		if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+			return fmt.Errorf("could not save updated configuration: %w", err)
+		}
+		fmt.Printf("base template set to '%s'\n", args[0])
+	}
 
 	return nil
 }
 
-func (ctx *Context) handleCreate(args []string) error {
-	if args[0] == "" {
+func (ctx *Context) handleCreate(name string, content []byte, force bool) error {
+	if name == "" {
 		return fmt.Errorf(missingTemplate)
 	}
-	name := args[0]
-	_, err := config.FindTemplateByName(ctx.cfg, name)
-	if err == nil {
-		return errors.New("template already exists")
+	if err := ctx.saveTemplate(name, content, force); err != nil {
+		return err
+	}
+
+	fmt.Printf("template '%s' created\n", name)
+	return nil
+}
+
+// saveTemplate writes content as the named template, registers it in
+// the configuration and saves it. An existing template is only
+// overwritten when forced.
+func (ctx *Context) saveTemplate(name string, content []byte, force bool) error {
+	if err := ctx.storeTemplate(name, content, force); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+	return nil
+}
+
+// storeTemplate writes content as the named template and registers it
+// in the configuration without saving the configuration
+func (ctx *Context) storeTemplate(name string, content []byte, force bool) error {
+	if _, err := config.FindBundleByName(ctx.cfg, name); err == nil {
+		return fmt.Errorf("a bundle named '%s' already exists", name)
+	}
+	index, err := config.GetTemplateIndexByName(ctx.cfg, name)
+	if err == nil && !force {
+		return fmt.Errorf("template '%s' already exists, use --force to overwrite it", name)
 	}
 
-	path := ctx.projectDir + "/" + name + ".gitignore"
-	templ := &structs.Template{
+	templ := structs.Template{
 		Name: name,
-		Path: path,
+		Path: generator.CreateTemplatePath(ctx.projectDir, name),
+	}
+	if err == nil {
+		templ = ctx.cfg.Templates[index]
 	}
-	ctx.cfg.Templates = append(ctx.cfg.Templates, *templ)
 
-	if err = generator.CreateEmptyTemplateFile(ctx.projectDir, templ.Name); err != nil {
+	if err := generator.WriteTemplateFile(templ.Path, content); err != nil {
 		return fmt.Errorf("could not create template file: %w", err)
 	}
 
-	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
-		return fmt.Errorf("could not save updated configuration: %w", err)
+	if index < 0 {
+		return config.AddTemplate(ctx.cfg, templ)
 	}
-
-	fmt.Printf("template '%s' created\n", name)
 	return nil
 }
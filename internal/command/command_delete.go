@@ -13,13 +13,19 @@ func (ctx *Context) commandDelete(args []string) error {
 		return fmt.Errorf("no template name provided to delete")
 	}
 
-	name := args[0]
 	forced := len(args) > 1 && (args[1] == "--f" || args[1] == "--force")
 
 	if len(args) > 2 || (len(args) == 2 && !forced) {
 		return fmt.Errorf("invalid arguments provided")
 	}
 
+	name := args[0]
+	if templ, err := config.FindTemplateByName(ctx.cfg, name); err == nil {
+		name = templ.Name
+	} else if bundle, err := config.FindBundleByName(ctx.cfg, name); err == nil {
+		return fmt.Errorf("'%s' is a bundle. try gogi bundle delete %s", bundle.Name, bundle.Name)
+	}
+
 	var confirmationPrompt string
 	if !forced {
 		if ctx.cfg.Base == name {
@@ -63,6 +69,8 @@ func (ctx *Context) deleteTemplate(name string) error {
 		ctx.cfg.Base = ""
 		fmt.Println("base template deleted")
 	}
+	ctx.removeFromBundles(name)
+	ctx.removeFromBaseRules(name)
 
 	if err := generator.DeleteTemplateFile(ctx.projectDir, name); err != nil {
 		return fmt.Errorf("could not delete template file: %w", err)
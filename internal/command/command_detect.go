@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/detect"
+	"strings"
+)
+
+// commandDetect is the callback for the "detect" command
+// It lists the templates suggested for the project in the working
+// directory, and with --auto generates a .gitignore file from them
+func (ctx *Context) commandDetect(args []string) error {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	_, flags := splitArgs(args)
+	matches, installed, err := ctx.detectTemplates()
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Println("No known project files found.")
+		return nil
+	}
+	for _, match := range matches {
+		fmt.Printf("%s: %s\n", strings.Join(match.Files, ", "), ctx.describeSuggestions(match.Rule.Templates))
+	}
+
+	if !hasFlag(flags, "--auto", "-a") {
+		if len(installed) > 0 {
+			fmt.Printf("Run 'gogi --auto' to generate a .gitignore from '%s'\n", strings.Join(installed, "', '"))
+		}
+		return nil
+	}
+	if len(installed) == 0 {
+		return fmt.Errorf("none of the suggested templates are installed. try 'gogi import' or 'gogi install'")
+	}
+	return ctx.generateTemplates(installed, flags, opts,
+		fmt.Sprintf("Generated .gitignore file from detected templates '%s'", strings.Join(installed, "', '")))
+}
+
+// detectTemplates inspects the working directory with the configured and
+// default rules. It returns the matched rules and the names of the
+// suggested templates that are installed.
+func (ctx *Context) detectTemplates() ([]detect.Match, []string, error) {
+	matches, err := detect.Detect(ctx.cwd, detect.Rules(ctx.cfg.Detect))
+	if err != nil {
+		return nil, nil, err
+	}
+	var installed []string
+	for _, name := range detect.Templates(matches) {
+		if templ, err := config.FindTemplateByName(ctx.cfg, name); err == nil {
+			installed = append(installed, templ.Name)
+		}
+	}
+	return matches, installed, nil
+}
+
+// describeSuggestions lists the template names, marking those that are
+// not installed
+func (ctx *Context) describeSuggestions(names []string) string {
+	described := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := config.FindTemplateByName(ctx.cfg, name); err != nil {
+			name += " (not installed)"
+		}
+		described = append(described, name)
+	}
+	return strings.Join(described, ", ")
+}
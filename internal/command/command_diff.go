@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/diff"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/ignore"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	diffSame   = 0
+	diffDiffer = 1
+	diffError  = 2
+)
+
+// commandDiff is the callback for the "diff" command
+// It shows how gogi generate would change the project .gitignore. Like
+// diff it exits with 0 when nothing would change, 1 when something would
+// and 2 on errors.
+func (ctx *Context) commandDiff(args []string) error {
+	differ, err := ctx.diffGitignore(args)
+	if err != nil {
+		return &exitError{code: diffError, err: err}
+	}
+	if differ {
+		return &exitError{code: diffDiffer}
+	}
+	return nil
+}
+
+// diffGitignore prints the differences between the project .gitignore and
+// the rendered templates, and reports whether there are any
+func (ctx *Context) diffGitignore(args []string) (bool, error) {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return false, err
+	}
+	names, flags := splitArgs(args)
+	if len(names) == 0 {
+		base, _ := config.ResolveBase(ctx.cfg, ctx.cwd)
+		if base == "" {
+			return false, fmt.Errorf("no template name provided and no base template is set")
+		}
+		names = []string{base}
+	}
+	templates, err := ctx.findTemplates(names)
+	if err != nil {
+		return false, err
+	}
+	rendered, _, err := generator.ComposeTemplates(templates, opts)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("unable to read .gitignore file: %w", err)
+	}
+	// the header records when the file was generated and is not part of
+	// the templates, so only the content below it is compared
+	if _, body, ok, err := generator.ParseProvenance(current); err == nil && ok {
+		current = body
+	}
+
+	if hasFlag(flags, "--semantic", "-s") {
+		removed, added := diff.Patterns(ignore.Parse(current), ignore.Parse(rendered))
+		for _, p := range removed {
+			fmt.Printf("-%s\n", p.Raw)
+		}
+		for _, p := range added {
+			fmt.Printf("+%s\n", p.Raw)
+		}
+		return len(removed)+len(added) > 0, nil
+	}
+
+	unified := diff.Unified(".gitignore", strings.Join(names, ", ")+" (rendered)", generator.SplitLines(current), generator.SplitLines(rendered), 3)
+	fmt.Print(unified)
+	return unified != "", nil
+}
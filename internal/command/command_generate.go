@@ -2,30 +2,41 @@ package command
 
 import (
 	"fmt"
-	"github.com/SQUASHD/gogi/internal/config"
 	"github.com/SQUASHD/gogi/internal/generator"
 	"os"
+	"strings"
 )
 
 // commandGenerate is the callback for the "generate" command
-// It generates a .gitignore file from the given template
+// It generates a .gitignore file from the given templates
 func (ctx *Context) commandGenerate(args []string) error {
-	if len(args) == 0 {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	names, flags := splitArgs(args)
+	if len(names) == 0 {
 		return fmt.Errorf("no template name provided")
 	}
+	return ctx.generateTemplates(names, flags, opts,
+		fmt.Sprintf("Generated .gitignore file from template '%s'", strings.Join(names, "', '")))
+}
 
-	name := args[0]
-	force := false
-	if len(args) > 1 {
-		force = args[1] == "--force" || args[1] == "-f"
+// generateTemplates writes the .gitignore file from the named templates,
+// checking them against the lockfile and asking before an existing file
+// is overwritten unless --force is given. It prints done and the merge
+// result once the file is written.
+func (ctx *Context) generateTemplates(names, flags []string, opts generator.Options, done string) error {
+	templates, err := ctx.findTemplates(names)
+	if err != nil {
+		return err
 	}
-
-	templ, err := config.FindTemplateByName(ctx.cfg, name)
+	locked, err := openLock(ctx.cwd, flags)
 	if err != nil {
 		return err
 	}
-
-	if err := generator.CheckWhetherTemplateExists(templ.Path); err != nil {
+	opts, err = locked.guard(templates, opts)
+	if err != nil {
 		return err
 	}
 
@@ -34,7 +45,7 @@ func (ctx *Context) commandGenerate(args []string) error {
 		return err
 	}
 
-	if exists && !force {
+	if exists && !hasFlag(flags, "--force", "-f", "--f") {
 		confirmMsg := "A .gitignore file already exists. Do you want to overwrite it?"
 		confirmed, err := ctx.ConfirmAction(confirmMsg, os.Stdin, os.Stdout)
 		if err != nil {
@@ -46,10 +57,15 @@ func (ctx *Context) commandGenerate(args []string) error {
 		}
 	}
 
-	if err := generator.GenerateGitignore(templ.Path, ctx.cwd); err != nil {
+	result, err := generator.GenerateGitignore(ctx.cwd, opts, templates...)
+	if err != nil {
+		return err
+	}
+	if err := locked.save(true); err != nil {
 		return err
 	}
 
-	fmt.Printf("Generated .gitignore file from template '%s'\n", name)
+	fmt.Println(done)
+	printMergeResult(opts, result)
 	return nil
 }
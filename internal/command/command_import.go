@@ -0,0 +1,117 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/fetch"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commandImport is the callback for the "import" command
+// It creates a template from an existing .gitignore file, a URL or the
+// configured template sources
+func (ctx *Context) commandImport(args []string) error {
+	url, args, hasURL, err := takeFlagValue(args, "--url")
+	if err != nil {
+		return err
+	}
+	positional, flags := splitArgs(args)
+	if len(positional) == 0 || len(positional) > 2 || (hasURL && len(positional) != 1) {
+		return fmt.Errorf("expected a template name and a file, --url or nothing to use the configured sources")
+	}
+	name := strings.ToLower(positional[0])
+	if err := checkIfReservedWord(name); err != nil {
+		return err
+	}
+	force := hasFlag(flags, "-f", "--force")
+
+	var content []byte
+	var source string
+	switch {
+	case len(positional) == 2:
+		content, err = ctx.readSource(positional[1])
+		source = positional[1]
+	case hasURL:
+		content, err = ctx.fetchURL(url, hasFlag(flags, "--offline"))
+		source = url
+	default:
+		content, source, err = ctx.fetchFromSources(name, hasFlag(flags, "--offline"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.storeTemplate(name, content, force); err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		index, _ := config.GetTemplateIndexByName(ctx.cfg, name)
+		ctx.cfg.Templates[index].Source = source
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+
+	fmt.Printf("template '%s' imported from %s\n", name, source)
+	return nil
+}
+
+// readSource reads a file relative to the working directory, or stdin
+// when source is "-"
+func (ctx *Context) readSource(source string) ([]byte, error) {
+	if source == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading input: %w", err)
+		}
+		return content, nil
+	}
+
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(ctx.cwd, source)
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", source, err)
+	}
+	return content, nil
+}
+
+// fetchURL fetches a template over HTTP through the cache in the
+// project directory
+func (ctx *Context) fetchURL(url string, offline bool) ([]byte, error) {
+	client := fetch.NewClient(filepath.Join(ctx.projectDir, "cache"))
+	client.Offline = offline
+	resp, err := client.Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Stale {
+		fmt.Printf("could not reach %s, using the cached copy\n", url)
+	}
+	return resp.Content, nil
+}
+
+// fetchFromSources looks for the named template on each configured
+// source in order and returns the first one found along with its URL
+func (ctx *Context) fetchFromSources(name string, offline bool) ([]byte, string, error) {
+	if len(ctx.cfg.Sources) == 0 {
+		return nil, "", fmt.Errorf("no template sources are configured")
+	}
+	for _, src := range ctx.cfg.Sources {
+		url := strings.TrimSuffix(src.URL, "/") + "/" + name + ".gitignore"
+		content, err := ctx.fetchURL(url, offline)
+		if errors.Is(err, fetch.ErrNotFound) || errors.Is(err, fetch.ErrNotCached) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("source '%s': %w", src.Name, err)
+		}
+		return content, url, nil
+	}
+	return nil, "", fmt.Errorf("template '%s' was not found on any source", name)
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/collection"
+	"github.com/SQUASHD/gogi/internal/config"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// commandImportCollection is the callback for the "import-collection" command
+// It registers every template of a local clone of github/gitignore
+func (ctx *Context) commandImportCollection(args []string) error {
+	only, args, _, err := takeFlagValue(args, "--only")
+	if err != nil {
+		return err
+	}
+	positional, flags := splitArgs(args)
+	if len(positional) != 1 {
+		return fmt.Errorf("expected the directory of the collection to import")
+	}
+	prefix := hasFlag(flags, "--prefix")
+	force := hasFlag(flags, "-f", "--force")
+
+	dir := positional[0]
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(ctx.cwd, dir)
+	}
+	entries, err := collection.Scan(dir)
+	if err != nil {
+		return err
+	}
+
+	filters := splitList(only)
+	taken := map[string]bool{}
+	imported, skipped := 0, 0
+	for _, entry := range entries {
+		if !matchesAny(filters, entry) {
+			continue
+		}
+
+		name := entry.Name
+		if prefix || taken[name] {
+			name = entry.PrefixedName()
+		}
+		if taken[name] {
+			fmt.Printf("skipping %s: name '%s' is used by another template of the collection\n", entry.Path, name)
+			skipped++
+			continue
+		}
+		taken[name] = true
+
+		if err := checkIfReservedWord(name); err != nil {
+			fmt.Printf("skipping %s: %v\n", entry.Path, err)
+			skipped++
+			continue
+		}
+		if _, err := config.FindTemplateByName(ctx.cfg, name); err == nil && !force {
+			fmt.Printf("skipping %s: template '%s' already exists\n", entry.Path, name)
+			skipped++
+			continue
+		}
+
+		content, err := os.ReadFile(entry.Path)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", entry.Path, err)
+		}
+		if err := ctx.storeTemplate(name, content, force); err != nil {
+			return err
+		}
+		imported++
+	}
+
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+	fmt.Printf("imported %d template(s), skipped %d\n", imported, skipped)
+	return nil
+}
+
+// matchesAny reports whether the entry matches any of the glob filters,
+// by its name or its prefixed name. No filters match every entry.
+func matchesAny(filters []string, entry collection.Entry) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		for _, name := range []string{entry.Name, entry.PrefixedName()} {
+			if ok, _ := path.Match(strings.ToLower(filter), name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/lint"
+	"os"
+	"path/filepath"
+)
+
+// commandLint is the callback for the "lint" command
+// It checks templates, or the project .gitignore, for common mistakes.
+// Templates are linted as written, directives and variables included, so
+// that every issue points at a line of the file and --fix can rewrite it.
+func (ctx *Context) commandLint(args []string) error {
+	names, flags := splitArgs(args)
+	fix := hasFlag(flags, "--fix")
+
+	var paths []string
+	if hasFlag(flags, "--project", "-p") {
+		if len(names) > 0 {
+			return fmt.Errorf("--project can not be combined with template names")
+		}
+		paths = append(paths, filepath.Join(ctx.cwd, ".gitignore"))
+	} else {
+		if len(names) == 0 {
+			for _, templ := range ctx.cfg.Templates {
+				names = append(names, templ.Name)
+			}
+		}
+		templates, err := ctx.findTemplates(names)
+		if err != nil {
+			return err
+		}
+		for _, templ := range templates {
+			paths = append(paths, templ.Path)
+		}
+	}
+
+	var issues []lint.Issue
+	for _, path := range paths {
+		fileIssues, err := lintFile(path, fix)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if lint.HasErrors(issues) {
+		return fmt.Errorf("lint found errors")
+	}
+	if len(issues) == 0 {
+		fmt.Println("no problems found")
+	}
+	return nil
+}
+
+// lintFile lints the file at path, applying the safe fixes first when fix is set
+func lintFile(path string, fix bool) ([]lint.Issue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	if fix {
+		fixed, changed := lint.Fix(content)
+		if changed > 0 {
+			if err := os.WriteFile(path, fixed, 0644); err != nil {
+				return nil, fmt.Errorf("unable to write %s: %w", path, err)
+			}
+			fmt.Printf("fixed %d line(s) in %s\n", changed, path)
+		}
+		content = fixed
+	}
+	return lint.Lint(path, content), nil
+}
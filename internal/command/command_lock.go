@@ -0,0 +1,130 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/lock"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"os"
+	"strings"
+)
+
+// templateLock checks the templates a command generates from against the
+// .gogi.lock of the project and records the ones it used
+type templateLock struct {
+	path   string
+	lock   *lock.Lock
+	update bool
+	used   []lock.Entry
+}
+
+// openLock loads the lockfile of the project in dir. Without a lockfile
+// it returns nil, and nothing is checked, unless --lock asks for one to
+// be written. --update accepts templates that changed since they were
+// locked.
+func openLock(dir string, flags []string) (*templateLock, error) {
+	path := lock.Path(dir)
+	l, err := lock.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if !hasFlag(flags, "--lock") {
+			return nil, nil
+		}
+		l, err = &lock.Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", lock.FileName, err)
+	}
+	return &templateLock{path: path, lock: l, update: hasFlag(flags, "--update")}, nil
+}
+
+// guard checks the given templates against the lock and returns opts
+// with a loader that checks the templates they include as well
+func (l *templateLock) guard(templates []structs.Template, opts generator.Options) (generator.Options, error) {
+	if l == nil {
+		return opts, nil
+	}
+	for _, templ := range templates {
+		content, err := os.ReadFile(templ.Path)
+		if err != nil {
+			return opts, fmt.Errorf("unable to open template file: %w", err)
+		}
+		if err := l.check(templ, content); err != nil {
+			return opts, err
+		}
+	}
+	load := opts.Load
+	opts.Load = func(name string) (structs.Template, []byte, error) {
+		templ, content, err := load(name)
+		if err != nil {
+			return templ, content, err
+		}
+		return templ, content, l.check(templ, content)
+	}
+	return opts, nil
+}
+
+// check compares the content of a template to its entry in the lock and
+// records it as used
+func (l *templateLock) check(templ structs.Template, content []byte) error {
+	entry := lock.Entry{Name: templ.Name, Hash: generator.ContentHash(content), Source: templ.Source}
+	if locked, ok := l.lock.Find(templ.Name); ok && locked.Hash != entry.Hash && !l.update {
+		return fmt.Errorf("template '%s' changed since it was locked in %s. review it with gogi verify and use --update to accept the change", templ.Name, lock.FileName)
+	}
+	for _, used := range l.used {
+		if strings.EqualFold(used.Name, entry.Name) {
+			return nil
+		}
+	}
+	l.used = append(l.used, entry)
+	return nil
+}
+
+// save writes the templates used to the lockfile. With replace the
+// entries of the templates that were not used are dropped.
+func (l *templateLock) save(replace bool) error {
+	if l == nil {
+		return nil
+	}
+	if replace {
+		l.lock.Templates = nil
+	}
+	for _, entry := range l.used {
+		l.lock.Set(entry)
+	}
+	return l.lock.Save(l.path)
+}
+
+// commandVerify is the callback for the "verify" command
+// It recomputes the hashes of the templates in the project .gogi.lock and
+// reports the ones that changed
+func (ctx *Context) commandVerify(args []string) error {
+	path := lock.Path(ctx.cwd)
+	l, err := lock.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no %s in %s. generate with --lock to write one", lock.FileName, ctx.cwd)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", lock.FileName, err)
+	}
+
+	var changed int
+	for _, entry := range l.Templates {
+		_, content, err := ctx.loadTemplate(entry.Name)
+		if err != nil {
+			fmt.Printf("missing  %s\n", entry.Name)
+			changed++
+			continue
+		}
+		if hash := generator.ContentHash(content); hash != entry.Hash {
+			fmt.Printf("changed  %s (locked %s, now %s)\n", entry.Name, entry.Hash, hash)
+			changed++
+			continue
+		}
+		fmt.Printf("ok       %s\n", entry.Name)
+	}
+	if changed > 0 {
+		return fmt.Errorf("%d of %d locked templates changed", changed, len(l.Templates))
+	}
+	return nil
+}
@@ -3,43 +3,48 @@ package command
 import (
 	"fmt"
 	"github.com/SQUASHD/gogi/internal/config"
-	"github.com/SQUASHD/gogi/internal/generator"
-	"os"
+	"strings"
 )
 
-// HandleQuickGogi tries to create a .gitignore file based on the template
-// designated as the base template
-func (ctx *Context) HandleQuickGogi() error {
-	baseTempl := ctx.cfg.Base
-	if baseTempl == "" {
-		return fmt.Errorf("no base template is set. try 'gogi base' or 'gogi help'")
-	}
-	templ, err := config.FindTemplateByName(ctx.cfg, baseTempl)
+// HandleQuickGogi tries to create a .gitignore file from the given
+// templates, or from the base template for the current directory when
+// no names are given. Template variables can be set with --set key=value.
+func (ctx *Context) HandleQuickGogi(args ...string) error {
+	args, opts, err := ctx.generatorOptions(args)
 	if err != nil {
 		return err
 	}
+	names, flags := splitArgs(args)
+	if len(names) == 0 {
+		baseTempl, _ := config.ResolveBase(ctx.cfg, ctx.cwd)
+		suggested := ctx.suggestDetected(baseTempl)
+		if baseTempl == "" {
+			if suggested {
+				return fmt.Errorf("no base template is set. try 'gogi --auto', 'gogi base' or 'gogi help'")
+			}
+			return fmt.Errorf("no base template is set. try 'gogi base' or 'gogi help'")
+		}
+		return ctx.generateTemplates([]string{baseTempl}, flags, opts, "Successfully created .gitignore template from base.")
+	}
+	return ctx.generateTemplates(names, flags, opts, "Successfully created .gitignore from the given templates.")
+}
 
-	exists, err := generator.DoesGitignoreExist(ctx.cwd)
+// suggestDetected points out the installed templates detected for the
+// project other than the base template, and reports whether there were any
+func (ctx *Context) suggestDetected(base string) bool {
+	_, installed, err := ctx.detectTemplates()
 	if err != nil {
-		return fmt.Errorf("error determining whether .gitignore exists: %v", err)
+		return false
 	}
-
-	if exists {
-		confirmationPrompt := "A .gitignore file already exists.\nOverwrite?"
-		confirmed, err := ctx.ConfirmAction(confirmationPrompt, os.Stdin, os.Stdout)
-		if err != nil {
-			return err
-		}
-		if !confirmed {
-			fmt.Println(OperationCancelledString)
-			return nil
+	var others []string
+	for _, name := range installed {
+		if !strings.EqualFold(name, base) {
+			others = append(others, name)
 		}
 	}
-
-	err = generator.GenerateGitignore(templ.Path, ctx.cwd)
-	if err != nil {
-		return err
+	if len(others) == 0 {
+		return false
 	}
-	fmt.Println("Successfully created .gitignore template from base.")
-	return nil
+	fmt.Printf("Detected templates '%s' for this project. Run 'gogi --auto' to use them.\n", strings.Join(others, "', '"))
+	return true
 }
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/registry"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"strings"
+)
+
+// commandRemote is the callback for the "remote" command
+// It adds, lists and removes the template registries gogi installs from
+func (ctx *Context) commandRemote(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		return ctx.listRemotes()
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) != 3 {
+			return fmt.Errorf("expected a remote name and url")
+		}
+		name := strings.ToLower(args[1])
+		if strings.Contains(name, "/") || name == "" {
+			return fmt.Errorf("invalid remote name '%s'", args[1])
+		}
+		if _, ok := ctx.findRemote(name); ok {
+			return fmt.Errorf("remote '%s' already exists", name)
+		}
+		ctx.cfg.Remotes = append(ctx.cfg.Remotes, structs.Remote{Name: name, URL: args[2]})
+		if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+			return fmt.Errorf("could not save updated configuration: %w", err)
+		}
+		fmt.Printf("remote '%s' added\n", name)
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("expected a remote name")
+		}
+		i, ok := ctx.findRemote(args[1])
+		if !ok {
+			return fmt.Errorf("remote '%s' not found", args[1])
+		}
+		ctx.cfg.Remotes = append(ctx.cfg.Remotes[:i], ctx.cfg.Remotes[i+1:]...)
+		if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+			return fmt.Errorf("could not save updated configuration: %w", err)
+		}
+		fmt.Printf("remote '%s' removed\n", args[1])
+	default:
+		return fmt.Errorf("unknown remote command '%s', expected add, list or remove", args[0])
+	}
+	return nil
+}
+
+// listRemotes prints the configured remotes along with their templates
+func (ctx *Context) listRemotes() error {
+	if len(ctx.cfg.Remotes) == 0 {
+		fmt.Println("you don't have any remotes!")
+		fmt.Println("try gogi remote add name url to add one")
+		return nil
+	}
+	for _, remote := range ctx.cfg.Remotes {
+		fmt.Printf("%s: %s\n", remote.Name, remote.URL)
+		infos, err := registry.NewClient(remote.URL).List()
+		if err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		for _, info := range infos {
+			fmt.Printf("- %s/%s\n", remote.Name, info.Name)
+		}
+	}
+	return nil
+}
+
+// findRemote returns the index of the named remote
+func (ctx *Context) findRemote(name string) (int, bool) {
+	for i, remote := range ctx.cfg.Remotes {
+		if strings.EqualFold(remote.Name, name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// commandInstall is the callback for the "install" command
+// It downloads a template from a remote and registers it locally
+func (ctx *Context) commandInstall(args []string) error {
+	as, args, _, err := takeFlagValue(args, "--as")
+	if err != nil {
+		return err
+	}
+	positional, flags := splitArgs(args)
+	if len(positional) != 1 {
+		return fmt.Errorf("expected a template as remote/template-name")
+	}
+	remoteName, templName, ok := strings.Cut(positional[0], "/")
+	if !ok || remoteName == "" || templName == "" {
+		return fmt.Errorf("expected a template as remote/template-name")
+	}
+	i, ok := ctx.findRemote(remoteName)
+	if !ok {
+		return fmt.Errorf("remote '%s' not found", remoteName)
+	}
+
+	name := strings.ToLower(templName)
+	if as != "" {
+		name = strings.ToLower(as)
+	}
+	if err := checkIfReservedWord(name); err != nil {
+		return err
+	}
+
+	client := registry.NewClient(ctx.cfg.Remotes[i].URL)
+	content, err := client.Get(templName)
+	if err != nil {
+		return err
+	}
+	if err := ctx.storeTemplate(name, content, hasFlag(flags, "-f", "--force")); err != nil {
+		return err
+	}
+	index, _ := config.GetTemplateIndexByName(ctx.cfg, name)
+	ctx.cfg.Templates[index].Source = client.TemplateURL(templName)
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+
+	fmt.Printf("template '%s' installed from %s\n", name, positional[0])
+	return nil
+}
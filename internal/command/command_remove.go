@@ -0,0 +1,73 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"os"
+	"strings"
+)
+
+// commandRemove is the callback for the "remove" command
+// It strips the lines a template contributed out of the project .gitignore
+func (ctx *Context) commandRemove(args []string) error {
+	names, flags := splitArgs(args)
+	if len(names) != 1 {
+		return fmt.Errorf("expected exactly one template name to remove")
+	}
+	name := names[0]
+	forced := hasFlag(flags, "--f", "-f", "--force")
+
+	templ, err := config.FindTemplateByName(ctx.cfg, name)
+	if err != nil {
+		return fmt.Errorf("could not find template '%s'", name)
+	}
+	name = templ.Name
+	templContent, err := os.ReadFile(templ.Path)
+	if err != nil {
+		return fmt.Errorf("unable to open template file: %w", err)
+	}
+
+	content, err := generator.ReadGitignore(ctx.cwd)
+	if err != nil {
+		return err
+	}
+	updated, removed, err := generator.RemoveBlock(content, name, templContent)
+	if err != nil {
+		return err
+	}
+	updated, err = generator.RefreshProvenance(updated, func(names []string) []string {
+		kept := names[:0]
+		for _, n := range names {
+			if !strings.EqualFold(n, name) {
+				kept = append(kept, n)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("The following lines will be removed from .gitignore:")
+	for _, line := range removed {
+		fmt.Printf("- %s\n", line)
+	}
+	if !forced {
+		confirmed, err := ctx.ConfirmAction("Remove these lines?", os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println(OperationCancelledString)
+			return nil
+		}
+	}
+
+	if err := generator.WriteGitignore(ctx.cwd, updated, false); err != nil {
+		return err
+	}
+
+	fmt.Printf("removed template '%s' from gitignore file\n", name)
+	return nil
+}
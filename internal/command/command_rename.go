@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/SQUASHD/gogi/internal/config"
 	"github.com/SQUASHD/gogi/internal/generator"
+	"strings"
 )
 
 // commandRename handles renaming a template
@@ -17,23 +18,29 @@ func (ctx *Context) commandRename(args []string) error {
 		return err
 	}
 
-	oldName := args[0]
-	newName := args[1]
-	templIdx, err := config.GetTemplateIndexByName(ctx.cfg, oldName)
+	newName := strings.ToLower(args[1])
+	templIdx, err := config.GetTemplateIndexByName(ctx.cfg, args[0])
 	if err != nil {
-		return fmt.Errorf("could not find template '%s'", oldName)
+		return fmt.Errorf("could not find template '%s'", args[0])
 	}
+	oldName := ctx.cfg.Templates[templIdx].Name
 
 	_, err = config.FindTemplateByName(ctx.cfg, newName)
 	if err == nil {
 		return fmt.Errorf("template '%s' already exists", newName)
 	}
+	if _, err := config.FindBundleByName(ctx.cfg, newName); err == nil {
+		return fmt.Errorf("a bundle named '%s' already exists", newName)
+	}
 
 	if ctx.cfg.Base == oldName {
 		ctx.cfg.Base = newName
 		fmt.Printf("base template set to '%s'\n", newName)
 	}
 
+	ctx.renameInBundles(oldName, newName)
+	ctx.renameInBaseRules(oldName, newName)
+
 	newTemplatePath := generator.GenerateTemplatePath(ctx.projectDir, newName)
 	ctx.cfg.Templates[templIdx].Name = newName
 	if err := generator.RenameTemplateFile(ctx.projectDir, oldName, newName); err != nil {
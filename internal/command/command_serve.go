@@ -0,0 +1,51 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/registry"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const defaultServeAddr = "localhost:8080"
+
+// commandServe is the callback for the "serve" command
+// It serves a template registry over HTTP until the process is stopped
+func (ctx *Context) commandServe(args []string) error {
+	handler, addr, err := ctx.newRegistryHandler(args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("serving templates on http://%s\n", addr)
+	return http.ListenAndServe(addr, handler)
+}
+
+// newRegistryHandler builds the registry server from the serve flags
+func (ctx *Context) newRegistryHandler(args []string) (http.Handler, string, error) {
+	addr, args, _, err := takeFlagValue(args, "--addr")
+	if err != nil {
+		return nil, "", err
+	}
+	dir, args, _, err := takeFlagValue(args, "--dir")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(args) > 0 {
+		return nil, "", fmt.Errorf("invalid arguments provided")
+	}
+	if addr == "" {
+		addr = defaultServeAddr
+	}
+	if dir == "" {
+		dir = filepath.Join(ctx.projectDir, "registry")
+	} else {
+		dir = ctx.resolvePath(dir)
+	}
+
+	store, err := registry.NewDirStore(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return registry.NewServer(store, os.Getenv("GOGI_TOKEN")), addr, nil
+}
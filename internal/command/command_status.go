@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"strings"
+)
+
+// commandStatus is the callback for the "status" command
+// It reads the provenance header of the project .gitignore and reports
+// whether the file was edited or its templates changed since it was
+// generated
+func (ctx *Context) commandStatus(args []string) error {
+	_, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	content, err := generator.ReadGitignore(ctx.cwd)
+	if err != nil {
+		return err
+	}
+	p, body, ok, err := generator.ParseProvenance(content)
+	if err != nil {
+		return fmt.Errorf("could not read the gogi header: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf(".gitignore has no gogi header. try gogi generate --header")
+	}
+
+	fmt.Printf("Generated by gogi %s at %s from '%s'\n",
+		p.Version, p.Generated.Local().Format("2006-01-02 15:04"), strings.Join(p.Templates, "', '"))
+
+	edited := generator.ContentHash(body) != p.Hash
+	if edited {
+		fmt.Println(".gitignore was edited since it was generated")
+	}
+
+	changed, err := ctx.changedTemplates(p, body, opts)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		fmt.Printf("templates changed since it was generated: '%s'\n", strings.Join(changed, "', '"))
+	}
+
+	if edited || len(changed) > 0 {
+		return fmt.Errorf(".gitignore has drifted")
+	}
+	fmt.Println(".gitignore is up to date")
+	return nil
+}
+
+// changedTemplates renders the templates recorded in the header again
+// and returns the names of those whose block no longer matches. The blocks
+// are rebuilt within body the way gogi update would, so in merge mode every
+// block leaves out the patterns around it.
+func (ctx *Context) changedTemplates(p generator.Provenance, body []byte, opts generator.Options) ([]string, error) {
+	templates, err := ctx.findTemplates(p.Templates)
+	if err != nil {
+		return nil, err
+	}
+	sections := make([]generator.Section, 0, len(templates))
+	for _, templ := range templates {
+		content, err := generator.ReadTemplate(templ, opts)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, generator.Section{Name: templ.Name, Content: content})
+	}
+	opts.Merge = p.Merge
+	expected, _, err := generator.SyncSections(body, sections, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not read .gitignore: %w", err)
+	}
+
+	expectedBlocks, err := generator.ParseBlocks(strings.Split(string(expected), "\n"))
+	if err != nil {
+		return nil, err
+	}
+	actualBlocks, err := generator.ParseBlocks(strings.Split(string(body), "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read .gitignore: %w", err)
+	}
+	var changed []string
+	for _, block := range expectedBlocks {
+		actual, ok := generator.FindBlock(actualBlocks, block.Name)
+		if !ok || strings.Join(actual.Lines, "\n") != strings.Join(block.Lines, "\n") {
+			changed = append(changed, block.Name)
+		}
+	}
+	return changed, nil
+}
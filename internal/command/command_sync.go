@@ -0,0 +1,133 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/diff"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/manifest"
+	"os"
+)
+
+// commandSync is the callback for the "sync" command
+// It rebuilds the gogi managed blocks of the ignore file from the project
+// manifest, or with --check fails when the file is out of date
+func (ctx *Context) commandSync(args []string) error {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	_, flags := splitArgs(args)
+	check := hasFlag(flags, "--check")
+
+	m, err := manifest.Find(ctx.cwd)
+	if errors.Is(err, manifest.ErrNotFound) {
+		return fmt.Errorf("%w. list the templates of the project in %s", err, manifest.FileName)
+	}
+	if err != nil {
+		return err
+	}
+	opts = manifestOptions(m, opts)
+	opts.Target.Stack = ctx.detectStack(m.Dir)
+	if check {
+		// a check has to be reproducible, so it never asks for values
+		opts.Prompt = nil
+	}
+
+	templates, err := ctx.findTemplates(m.Templates)
+	if err != nil {
+		return err
+	}
+	locked, err := openLock(m.Dir, flags)
+	if err != nil {
+		return err
+	}
+	opts, err = locked.guard(templates, opts)
+	if err != nil {
+		return err
+	}
+	sections := make([]generator.Section, 0, len(templates)+1)
+	names := make([]string, 0, len(templates))
+	for _, templ := range templates {
+		content, err := generator.ReadTemplate(templ, opts)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, generator.Section{Name: templ.Name, Content: content})
+		names = append(names, templ.Name)
+	}
+	if len(m.Patterns) > 0 {
+		sections = append(sections, generator.Section{Name: manifest.FileName, Content: m.PatternContent()})
+	}
+
+	current, err := os.ReadFile(m.OutputPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read %s: %w", m.Output, err)
+	}
+	_, currentBody, hasHeader, err := generator.ParseProvenance(current)
+	if err != nil {
+		return fmt.Errorf("could not read the gogi header of %s: %w", m.Output, err)
+	}
+	synced, result, err := generator.SyncSections(currentBody, sections, opts)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(synced, currentBody) {
+		if !check {
+			if err := locked.save(true); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("%s is up to date with %s\n", m.Output, manifest.FileName)
+		return nil
+	}
+	if check {
+		fmt.Print(diff.Unified(m.Output, m.Output+" (synced)", generator.SplitLines(currentBody), generator.SplitLines(synced), 3))
+		return fmt.Errorf("%s is out of date with %s. run gogi sync", m.Output, manifest.FileName)
+	}
+
+	if hasHeader || opts.Header {
+		synced = generator.WithProvenance(generator.NewProvenance(names, opts.Merge), synced)
+	}
+	if err := generator.WriteGenerated(m.OutputPath(), synced, true); err != nil {
+		return err
+	}
+	if err := locked.save(true); err != nil {
+		return err
+	}
+	fmt.Printf("synced %s with %s\n", m.Output, manifest.FileName)
+	printMergeResult(opts, result)
+	return nil
+}
+
+// manifestOptions applies the settings of the manifest to opts. Values
+// given on the command line take precedence over those of the manifest.
+// The platform falls back to the manifest defaults and the environment is
+// kept out, so the output does not depend on the machine gogi runs on.
+func manifestOptions(m *manifest.Manifest, opts generator.Options) generator.Options {
+	vars := make(map[string]string)
+	for name, value := range m.Vars {
+		vars[name] = value
+	}
+	for name, value := range opts.Vars {
+		vars[name] = value
+	}
+	opts.Vars = vars
+	opts.Merge = opts.Merge || m.Merge
+	if opts.Target.OS == "" {
+		opts.Target.OS = m.OS
+	}
+	if opts.Target.Arch == "" {
+		opts.Target.Arch = m.Arch
+	}
+	if opts.Target.OS == "" {
+		opts.Target.OS = manifest.DefaultOS
+	}
+	if opts.Target.Arch == "" {
+		opts.Target.Arch = manifest.DefaultArch
+	}
+	opts.Target.NoEnv = true
+	return opts
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/gitsync"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"path/filepath"
+)
+
+const defaultBranch = "main"
+
+// commandRepo is the callback for the "repo" command
+// It manages the template directory as a git repository with a remote
+func (ctx *Context) commandRepo(args []string) error {
+	branch, args, _, err := takeFlagValue(args, "--branch")
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		if ctx.cfg.Git.Remote == "" {
+			return fmt.Errorf("the template directory is not synced with a remote")
+		}
+		fmt.Printf("templates are synced with %s on branch '%s'\n", ctx.cfg.Git.Remote, ctx.gitBranch())
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("expected a single remote url")
+	}
+	if branch == "" {
+		branch = ctx.gitBranch()
+	}
+
+	if _, err := gitsync.Init(ctx.projectDir, args[0], branch, ctx.localFiles()...); err != nil {
+		return err
+	}
+	ctx.cfg.Git = structs.GitSync{Remote: args[0], Branch: branch}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+	fmt.Printf("templates are synced with %s on branch '%s'\n", args[0], branch)
+	return nil
+}
+
+// localFiles are the files of the template directory that only make sense
+// on this machine. The configuration holds absolute paths and settings
+// such as the editor, base rules and remotes, so only its shared part is
+// synced, see config.WriteShared. The registry folder is the default store
+// of gogi serve and is published through the registry instead.
+func (ctx *Context) localFiles() []string {
+	return []string{filepath.Base(ctx.configPath), "cache/", "registry/"}
+}
+
+// commandPush is the callback for the "push" command
+// It commits the template changes and the shared configuration and pushes
+// them to the remote
+func (ctx *Context) commandPush(args []string) error {
+	repo, err := gitsync.Open(ctx.projectDir, ctx.gitBranch())
+	if err != nil {
+		return err
+	}
+	if err := config.WriteShared(ctx.cfg, ctx.projectDir); err != nil {
+		return err
+	}
+	message, err := repo.Push()
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		fmt.Println("no template changes to commit, pushed to remote")
+		return nil
+	}
+	fmt.Printf("committed and pushed '%s'\n", message)
+	return nil
+}
+
+// commandPull is the callback for the "pull" command
+// It fast-forwards the templates to the remote, or reports the conflicts,
+// and applies the shared configuration pulled
+func (ctx *Context) commandPull(args []string) error {
+	repo, err := gitsync.Open(ctx.projectDir, ctx.gitBranch())
+	if err != nil {
+		return err
+	}
+	// the shared configuration as last synced tells the templates added
+	// or removed here since from those changed on the remote
+	base, err := config.ReadShared(ctx.projectDir)
+	if err != nil {
+		return err
+	}
+	conflicts, err := repo.Pull()
+	if errors.Is(err, gitsync.ErrDiverged) {
+		fmt.Println("could not fast-forward, these files changed both locally and on the remote:")
+		for _, file := range conflicts {
+			fmt.Printf("- %s\n", file)
+		}
+		return fmt.Errorf("resolve the conflicts in %s with git and try again", ctx.projectDir)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := config.ApplyShared(ctx.cfg, ctx.projectDir, base); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return fmt.Errorf("could not save updated configuration: %w", err)
+	}
+	fmt.Println("templates are up to date with the remote")
+	return nil
+}
+
+// gitBranch returns the configured branch or the default one
+func (ctx *Context) gitBranch() string {
+	if ctx.cfg.Git.Branch != "" {
+		return ctx.cfg.Git.Branch
+	}
+	return defaultBranch
+}
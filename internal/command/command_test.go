@@ -1,10 +1,20 @@
 package command
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/diff"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/lock"
+	"github.com/SQUASHD/gogi/internal/manifest"
 	"github.com/SQUASHD/gogi/internal/structs"
 )
 
@@ -73,6 +83,15 @@ func addGitIgnoreToTestDir(t *testing.T, ctx *Context) {
 	defer destinationFile.Close()
 }
 
+// writeTemplate writes the content of the named template to its file in
+// the project directory
+func writeTemplate(t *testing.T, ctx *Context, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(ctx.projectDir, name+".gitignore"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+}
+
 func TestAppendCommand(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -86,6 +105,8 @@ func TestAppendCommand(t *testing.T) {
 		{"append valid no gitignore", []string{"test2"}, false, true},
 		{"append invalid no gitignore", []string{"invalid"}, false, true},
 		{"append no args", []string{}, true, true},
+		{"append multiple", []string{"test1", "test2"}, true, false},
+		{"append merged", []string{"test1", "-m"}, true, false},
 	}
 
 	for _, tt := range tests {
@@ -105,6 +126,201 @@ func TestAppendCommand(t *testing.T) {
 	}
 }
 
+func TestRemoveCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		existing string
+		wantErr  bool
+		expected string
+	}{
+		{"remove block", []string{"test1", "--force"},
+			"mine\n\n# >>> gogi:test1\nfoo\n# <<< gogi:test1\n", false, "mine\n"},
+		{"remove keeps user lines", []string{"test2", "--force"},
+			"a\n# >>> gogi:test2\n# <<< gogi:test2\nb\n", false, "a\nb\n"},
+		{"remove template not in gitignore", []string{"test2", "--force"}, "mine\n", true, "mine\n"},
+		{"remove invalid template", []string{"invalid", "--force"}, "mine\n", true, "mine\n"},
+		{"remove no args", []string{}, "mine\n", true, "mine\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+
+			gitignorePath := filepath.Join(ctx.cwd, ".gitignore")
+			if err := os.WriteFile(gitignorePath, []byte(tt.existing), 0644); err != nil {
+				t.Fatalf("unable to write .gitignore file: %v", err)
+			}
+
+			err := ctx.commandRemove(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandRemove() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, _ := os.ReadFile(gitignorePath)
+			if string(got) != tt.expected {
+				t.Errorf("Expected .gitignore to be %q but got %q", tt.expected, string(got))
+			}
+		})
+	}
+}
+
+func TestTestCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"ignored path", []string{"test1", "debug.log"}, false},
+		{"expect ignored", []string{"test1", "debug.log", "build/", "--expect-ignored"}, false},
+		{"expect ignored fails", []string{"test1", "debug.log", "main.go", "--expect-ignored"}, true},
+		{"expect not ignored", []string{"test1", "keep.log", "main.go", "--expect-not-ignored"}, false},
+		{"expect not ignored fails", []string{"test1", "build/out", "--expect-not-ignored"}, true},
+		{"conflicting expectations", []string{"test1", "a", "--expect-ignored", "--expect-not-ignored"}, true},
+		{"rendered for target os", []string{"test1", "Thumbs.db", "--os", "windows", "--expect-ignored"}, false},
+		{"condition left out for other os", []string{"test1", "Thumbs.db", "--os", "linux", "--expect-ignored"}, true},
+		{"invalid template", []string{"invalid", "debug.log"}, true},
+		{"no args", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+
+			writeTemplate(t, ctx, "test1", "*.log\n!keep.log\nbuild/\n#gogi:if os=windows\nThumbs.db\n#gogi:endif\n")
+
+			err := ctx.commandTest(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadPaths(t *testing.T) {
+	paths, err := readPaths(strings.NewReader("a.log\n\n  b/c  \n"))
+	if err != nil {
+		t.Fatalf("readPaths() error = %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "a.log" || paths[1] != "b/c" {
+		t.Errorf("Expected paths [a.log b/c] but got %v", paths)
+	}
+}
+
+func TestWhyCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		wantErr  bool
+		expected []string
+	}{
+		{"ignored path", "debug.log", false, []string{
+			"debug.log is ignored\n", "pattern:  *.log\n", "source:   .gitignore:2\n", "template: test1 (written by gogi)\n"}},
+		{"ignored by nested gitignore", "sub/out.tmp", false, []string{
+			"pattern:  *.tmp\n", "source:   sub/.gitignore:1\n", "template: test2 (same pattern on line 1 of the rendered template)\n"}},
+		{"ignored through parent", "build/keep.log", false, []string{
+			"parent:   build/ is excluded", "pattern:  build/\n", "source:   .gitignore:3\n"}},
+		{"not ignored path", "main.go", false, []string{"main.go is not ignored: no pattern matches it\n"}},
+		{"absolute path", "", false, []string{"pattern:  *.tmp\n", "source:   sub/.gitignore:1\n"}},
+		{"outside of repository", "../elsewhere", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			t.Setenv("HOME", ctx.cwd)
+			t.Setenv("XDG_CONFIG_HOME", ctx.cwd)
+
+			files := map[string]string{
+				".gitignore":     "# >>> gogi:test1\n*.log\nbuild/\n# <<< gogi:test1\n",
+				"sub/.gitignore": "*.tmp\n",
+			}
+			if err := os.MkdirAll(filepath.Join(ctx.cwd, ".git"), 0755); err != nil {
+				t.Fatalf("unable to create .git directory: %v", err)
+			}
+			for name, content := range files {
+				path := filepath.Join(ctx.cwd, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("unable to create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("unable to write %s: %v", name, err)
+				}
+			}
+			writeTemplate(t, ctx, "test2", "#gogi:include test3\n")
+			writeTemplate(t, ctx, "test3", "*.tmp\n")
+			ctx.cfg.Templates = append(ctx.cfg.Templates, structs.Template{Name: "test3", Path: filepath.Join(ctx.projectDir, "test3.gitignore")})
+
+			path := tt.path
+			if path == "" {
+				path = filepath.Join(ctx.cwd, "sub", "out.tmp")
+			}
+			var out strings.Builder
+			err := ctx.explainPath(&out, path, generator.Options{Load: ctx.loadTemplate})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("explainPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("Expected the explanation to contain %q but got\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	for _, args := range [][]string{{}, {"a", "b"}} {
+		if err := ctx.commandWhy(args); err == nil {
+			t.Errorf("Expected an error for arguments %v", args)
+		}
+	}
+}
+
+func TestLintCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		content   string
+		project   string
+		wantErr   bool
+		fixedTmpl string
+	}{
+		{"lint all templates", []string{}, "*.log\n", "", false, "*.log\n"},
+		{"lint template with warnings", []string{"test1"}, "*.log  \n", "", false, "*.log  \n"},
+		{"lint template with errors", []string{"test1"}, "bin\\Debug\n", "", true, "bin\\Debug\n"},
+		{"lint and fix template", []string{"test1", "--fix"}, "*.log  \n*.log\n", "", false, "*.log\n"},
+		{"lint project", []string{"--project"}, "", "foo\\\n", true, ""},
+		{"lint project with names", []string{"test1", "--project"}, "", "", true, ""},
+		{"lint invalid template", []string{"invalid"}, "", "", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+
+			writeTemplate(t, ctx, "test1", tt.content)
+			if err := os.WriteFile(filepath.Join(ctx.cwd, ".gitignore"), []byte(tt.project), 0644); err != nil {
+				t.Fatalf("unable to write .gitignore file: %v", err)
+			}
+
+			err := ctx.commandLint(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandLint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, _ := os.ReadFile(ctx.cfg.Templates[0].Path)
+			if string(got) != tt.fixedTmpl && tt.fixedTmpl != "" {
+				t.Errorf("Expected template to be %q but got %q", tt.fixedTmpl, string(got))
+			}
+		})
+	}
+}
+
 func TestBaseCommand(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -134,6 +350,152 @@ func TestBaseCommand(t *testing.T) {
 	}
 }
 
+func TestBaseRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		rules         []structs.BaseRule
+		subdir        string
+		expectedBase  string
+		expectedIndex int
+	}{
+		{"no rules", nil, "work/api", "test1", -1},
+		{"prefix rule", []structs.BaseRule{{Path: "work", Template: "test2"}}, "work/api", "test2", 0},
+		{"prefix is not a partial name", []structs.BaseRule{{Path: "work", Template: "test2"}}, "workshop", "test1", -1},
+		{"glob rule matches parent", []structs.BaseRule{{Path: "w*/api", Template: "test2"}}, "work/api/cmd", "test2", 0},
+		{"first match wins", []structs.BaseRule{
+			{Path: "src", Template: "test1"},
+			{Path: "work/*", Template: "test2"},
+			{Path: "work", Template: "test1"},
+		}, "work/api", "test2", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			for i, rule := range tt.rules {
+				tt.rules[i].Path = filepath.Join(ctx.projectDir, rule.Path)
+			}
+			ctx.cfg.BaseRules = tt.rules
+			ctx.cwd = filepath.Join(ctx.projectDir, tt.subdir)
+
+			base, index := config.ResolveBase(ctx.cfg, ctx.cwd)
+			if base != tt.expectedBase || index != tt.expectedIndex {
+				t.Errorf("Expected base %s from rule %d but got %s from rule %d", tt.expectedBase, tt.expectedIndex, base, index)
+			}
+			if err := ctx.commandBase([]string{"--explain"}); err != nil {
+				t.Errorf("commandBase() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("add and remove rules", func(t *testing.T) {
+		ctx, cleanup := newTestContext(t)
+		defer cleanup()
+		if err := ctx.commandBase([]string{"test2", "--dir", "~/work"}); err != nil {
+			t.Fatalf("commandBase() error = %v", err)
+		}
+		if err := ctx.commandBase([]string{"test1", "--dir", "~/work"}); err != nil {
+			t.Fatalf("commandBase() error = %v", err)
+		}
+		expected := []structs.BaseRule{{Path: "~/work", Template: "test1"}}
+		if len(ctx.cfg.BaseRules) != 1 || ctx.cfg.BaseRules[0] != expected[0] {
+			t.Errorf("Expected rules %v but got %v", expected, ctx.cfg.BaseRules)
+		}
+		if ctx.cfg.Base != "test1" {
+			t.Errorf("Expected global base to stay test1 but got %s", ctx.cfg.Base)
+		}
+		if err := ctx.commandBase([]string{"--remove-dir", "~/work"}); err != nil {
+			t.Fatalf("commandBase() error = %v", err)
+		}
+		if len(ctx.cfg.BaseRules) != 0 {
+			t.Errorf("Expected no rules but got %v", ctx.cfg.BaseRules)
+		}
+		if err := ctx.commandBase([]string{"--remove-dir", "~/work"}); err == nil {
+			t.Errorf("Expected an error removing a missing rule")
+		}
+	})
+}
+
+func TestBundleCommand(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	for name, content := range map[string]string{"test1": "*.log\n", "test2": "*.exe\n"} {
+		writeTemplate(t, ctx, name, content)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"create bundle", []string{"create", "Backend", "test2", "TEST1"}, false},
+		{"create existing bundle", []string{"create", "backend", "test1"}, true},
+		{"create with unknown template", []string{"create", "frontend", "node"}, true},
+		{"create with template name", []string{"create", "test1", "test2"}, true},
+		{"create without templates", []string{"create", "frontend"}, true},
+		{"show bundle", []string{"show", "backend"}, false},
+		{"show unknown bundle", []string{"show", "frontend"}, true},
+		{"list bundles", []string{"list"}, false},
+		{"unknown subcommand", []string{"rename"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ctx.commandBundle(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	bundle, err := config.FindBundleByName(ctx.cfg, "backend")
+	if err != nil || strings.Join(bundle.Templates, ",") != "test2,test1" {
+		t.Fatalf("Expected bundle backend with test2,test1 but got %v, %v", bundle, err)
+	}
+
+	if err := ctx.commandGenerate([]string{"backend", "test1", "--force"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+	expected := "# >>> gogi:test2\n*.exe\n# <<< gogi:test2\n\n# >>> gogi:test1\n*.log\n# <<< gogi:test1\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q but got %q", expected, string(content))
+	}
+
+	if err := ctx.commandBase([]string{"backend"}); err != nil || ctx.cfg.Base != "backend" {
+		t.Errorf("Expected base to be backend but got %s, %v", ctx.cfg.Base, err)
+	}
+	if unknown := ctx.unknownQuickArg([]string{"backend", "test1"}); unknown != "" {
+		t.Errorf("Expected bundle names to be accepted as template names")
+	}
+	if err := ctx.commandCreate([]string{"backend"}); err == nil {
+		t.Errorf("Expected an error creating a template named like a bundle")
+	}
+	if err := ctx.commandDelete([]string{"backend", "--force"}); err == nil {
+		t.Errorf("Expected gogi delete to refuse deleting a bundle")
+	}
+
+	if err := ctx.commandRename([]string{"test2", "go"}); err != nil {
+		t.Fatalf("commandRename() error = %v", err)
+	}
+	if err := ctx.commandDelete([]string{"test1", "--force"}); err != nil {
+		t.Fatalf("commandDelete() error = %v", err)
+	}
+	if strings.Join(bundle.Templates, ",") != "go" {
+		t.Errorf("Expected bundle templates to follow renames and deletes but got %v", bundle.Templates)
+	}
+
+	if err := ctx.commandBundle([]string{"delete", "backend"}); err != nil {
+		t.Fatalf("commandBundle() error = %v", err)
+	}
+	if len(ctx.cfg.Bundles) != 0 || ctx.cfg.Base != "" {
+		t.Errorf("Expected the bundle and base to be deleted but got %v, %q", ctx.cfg.Bundles, ctx.cfg.Base)
+	}
+}
+
 func TestCreateCommand(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -150,53 +512,407 @@ func TestCreateCommand(t *testing.T) {
 		{"create with base flag2", []string{"test3", "-base"}, false, true, 3},
 		{"create malformed arg and base flag", []string{"test1", "-base"}, true, false, 2},
 		{"create with reserved word", []string{"help"}, true, false, 2},
+		{"create from file", []string{"test3", "--from", "source.gitignore"}, false, false, 3},
+		{"create from missing file", []string{"test3", "--from", "missing"}, true, false, 2},
+		{"create from without path", []string{"test3", "--from"}, true, false, 2},
+		{"create existing name forced", []string{"test1", "--from", "source.gitignore", "--force"}, false, false, 2},
+		{"create invalid flag", []string{"test3", "--bogus"}, true, false, 2},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cleanup := newTestContext(t)
 			defer cleanup()
+			writeSourceFile(t, ctx)
+
+			err := ctx.commandCreate(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(ctx.cfg.Templates) != tt.expectedLen {
+				t.Errorf("Expected templates to have length %d but got %d", tt.expectedLen, len(ctx.cfg.Templates))
+			}
+			if tt.baseChanged && ctx.cfg.Base != tt.args[0] {
+				t.Errorf("Expected base to be %s but got %s", tt.args[0], ctx.cfg.Base)
+			}
+		})
+	}
+}
+
+func writeSourceFile(t *testing.T, ctx *Context) string {
+	t.Helper()
+	path := filepath.Join(ctx.cwd, "source.gitignore")
+	if err := os.WriteFile(path, []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("unable to write source file: %v", err)
+	}
+	return path
+}
+
+func TestImportCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantErr     bool
+		expectedLen int
+	}{
+		{"import new template", []string{"imported", "source.gitignore"}, false, 3},
+		{"import mixed case name", []string{"Imported", "source.gitignore"}, false, 3},
+		{"import existing template", []string{"test1", "source.gitignore"}, true, 2},
+		{"import existing template forced", []string{"test1", "source.gitignore", "--force"}, false, 2},
+		{"import missing file", []string{"imported", "missing"}, true, 2},
+		{"import reserved word", []string{"help", "source.gitignore"}, true, 2},
+		{"import without file", []string{"imported"}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			writeSourceFile(t, ctx)
+
+			err := ctx.commandImport(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(ctx.cfg.Templates) != tt.expectedLen {
+				t.Errorf("Expected templates to have length %d but got %d", tt.expectedLen, len(ctx.cfg.Templates))
+			}
+			if tt.wantErr {
+				return
+			}
+
+			templ, err := config.FindTemplateByName(ctx.cfg, tt.args[0])
+			if err != nil {
+				t.Fatalf("Expected template %s to be registered", tt.args[0])
+			}
+			content, _ := os.ReadFile(templ.Path)
+			if string(content) != "*.log\n" {
+				t.Errorf("Expected template content to be copied but got %q", string(content))
+			}
+		})
+	}
+}
+
+func TestImportFromHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/team/node.gitignore" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("node_modules/\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		args           []string
+		sources        []structs.Source
+		wantErr        bool
+		expectedSource string
+	}{
+		{"import from url", []string{"node", "--url", server.URL + "/team/node.gitignore"}, nil, false,
+			server.URL + "/team/node.gitignore"},
+		{"import from missing url", []string{"node", "--url", server.URL + "/missing"}, nil, true, ""},
+		{"import url and file", []string{"node", "file", "--url", server.URL}, nil, true, ""},
+		{"import from sources", []string{"node"}, []structs.Source{
+			{Name: "other", URL: server.URL + "/other"},
+			{Name: "team", URL: server.URL + "/team/"},
+		}, false, server.URL + "/team/node.gitignore"},
+		{"import missing from sources", []string{"python"}, []structs.Source{{Name: "team", URL: server.URL + "/team"}}, true, ""},
+		{"import without sources", []string{"node"}, nil, true, ""},
+		{"import offline without cache", []string{"node", "--url", server.URL + "/team/node.gitignore", "--offline"}, nil, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			ctx.cfg.Sources = tt.sources
+
+			err := ctx.commandImport(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commandImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			templ, err := config.FindTemplateByName(ctx.cfg, tt.args[0])
+			if err != nil {
+				t.Fatalf("Expected template %s to be registered", tt.args[0])
+			}
+			if templ.Source != tt.expectedSource {
+				t.Errorf("Expected source %s but got %s", tt.expectedSource, templ.Source)
+			}
+			content, _ := os.ReadFile(templ.Path)
+			if string(content) != "node_modules/\n" {
+				t.Errorf("Expected template content to be fetched but got %q", string(content))
+			}
+		})
+	}
+}
+
+func TestImportCollectionCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantErr       bool
+		expectedNames []string
+	}{
+		{"import everything", []string{"collection"}, false,
+			[]string{"test1", "test2", "go", "global-go", "macos", "hugo"}},
+		{"import with prefix", []string{"collection", "--prefix"}, false,
+			[]string{"test1", "test2", "go", "global-go", "global-macos", "community-hugo"}},
+		{"import only some", []string{"collection", "--only", "go,mac*"}, false,
+			[]string{"test1", "test2", "go", "global-go", "macos"}},
+		{"existing names are skipped", []string{"collection", "--only", "test1"}, false,
+			[]string{"test1", "test2"}},
+		{"existing names are overwritten when forced", []string{"collection", "--only", "test1", "--force"}, false,
+			[]string{"test1", "test2"}},
+		{"only without value", []string{"collection", "--only"}, true, []string{"test1", "test2"}},
+		{"missing directory", []string{"missing"}, true, []string{"test1", "test2"}},
+		{"no args", []string{}, true, []string{"test1", "test2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+
+			for _, name := range []string{"Go.gitignore", "Test1.gitignore", "Global/macOS.gitignore",
+				"Global/Go.gitignore", "community/Golang/Hugo.gitignore"} {
+				path := filepath.Join(ctx.cwd, "collection", filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("unable to create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("*.out\n"), 0644); err != nil {
+					t.Fatalf("unable to write %s: %v", name, err)
+				}
+			}
+
+			err := ctx.commandImportCollection(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandImportCollection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var names []string
+			for _, templ := range ctx.cfg.Templates {
+				names = append(names, templ.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedNames, ",") {
+				t.Errorf("Expected templates %v but got %v", tt.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestRepoPushPullCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "gogi")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "gogi@example.com")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	if err := ctx.commandPush([]string{}); err == nil {
+		t.Errorf("Expected push to fail before the repository is set up")
+	}
+	if err := ctx.commandRepo([]string{}); err == nil {
+		t.Errorf("Expected repo to fail before a remote is set")
+	}
+
+	remote := filepath.Join(t.TempDir(), "templates.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("unable to create bare repository: %v: %s", err, out)
+	}
+	if err := ctx.commandRepo([]string{remote, "--branch", "templates"}); err != nil {
+		t.Fatalf("commandRepo() error = %v", err)
+	}
+	if ctx.cfg.Git.Remote != remote || ctx.cfg.Git.Branch != "templates" {
+		t.Errorf("Expected the remote to be saved in the configuration but got %+v", ctx.cfg.Git)
+	}
+	if _, _, err := ctx.newRegistryHandler([]string{}); err != nil {
+		t.Fatalf("newRegistryHandler() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ctx.projectDir, "registry", "node"), []byte("node_modules/\n"), 0644); err != nil {
+		t.Fatalf("unable to write registry template: %v", err)
+	}
+	if err := ctx.commandPush([]string{}); err != nil {
+		t.Errorf("commandPush() error = %v", err)
+	}
+	if err := ctx.commandPull([]string{}); err != nil {
+		t.Errorf("commandPull() error = %v", err)
+	}
+
+	// a second machine gets the templates with paths of its own and keeps
+	// its local settings
+	desktopDir := t.TempDir()
+	desktopCfg := structs.TemplateConfig{Editor: "vim", BaseRules: []structs.BaseRule{{Path: "~/work", Template: "go"}}}
+	desktop, err := NewCommandContext(&desktopCfg, desktopDir, desktopDir, filepath.Join(desktopDir, "config.json"))
+	if err != nil {
+		t.Fatalf("NewCommandContext() error = %v", err)
+	}
+	if err := desktop.commandRepo([]string{remote, "--branch", "templates"}); err != nil {
+		t.Fatalf("commandRepo() error = %v", err)
+	}
+	if err := desktop.commandPull([]string{}); err != nil {
+		t.Fatalf("commandPull() error = %v", err)
+	}
+	if len(desktopCfg.Templates) != 2 || desktopCfg.Templates[0].Path != filepath.Join(desktopDir, "test1.gitignore") {
+		t.Errorf("Expected the templates to point into the desktop template directory but got %+v", desktopCfg.Templates)
+	}
+	if _, err := os.Stat(desktopCfg.Templates[0].Path); err != nil {
+		t.Errorf("Expected the pulled template file to exist: %v", err)
+	}
+	if desktopCfg.Editor != "vim" || len(desktopCfg.BaseRules) != 1 {
+		t.Errorf("Expected the local settings to be kept but got %+v", desktopCfg)
+	}
+	tracked, err := exec.Command("git", "-C", desktopDir, "ls-files").Output()
+	if err != nil {
+		t.Fatalf("git ls-files error = %v", err)
+	}
+	if strings.Contains(string(tracked), "config.json") {
+		t.Errorf("Expected config.json to be kept out of the repository but got\n%s", tracked)
+	}
+	if strings.Contains(string(tracked), "registry/") {
+		t.Errorf("Expected the registry store to be kept out of the repository but got\n%s", tracked)
+	}
+
+	// a template created on one machine survives pulling the templates
+	// pushed from the other
+	writeTemplate(t, desktop, "desk", "*.desk\n")
+	desktopCfg.Templates = append(desktopCfg.Templates, structs.Template{Name: "desk", Path: filepath.Join(desktopDir, "desk.gitignore")})
+	if err := desktop.commandPush([]string{}); err != nil {
+		t.Fatalf("commandPush() error = %v", err)
+	}
+	writeTemplate(t, ctx, "local", "*.local\n")
+	ctx.cfg.Templates = append(ctx.cfg.Templates, structs.Template{Name: "local", Path: filepath.Join(ctx.projectDir, "local.gitignore")})
+	if err := ctx.commandPull([]string{}); err != nil {
+		t.Fatalf("commandPull() error = %v", err)
+	}
+	for _, name := range []string{"test1", "test2", "desk", "local"} {
+		templ, err := config.FindTemplateByName(ctx.cfg, name)
+		if err != nil {
+			t.Errorf("Expected template %s to be registered after the pull but got %+v", name, ctx.cfg.Templates)
+			continue
+		}
+		if filepath.Dir(templ.Path) != ctx.projectDir {
+			t.Errorf("Expected template %s in %s but got %s", name, ctx.projectDir, templ.Path)
+		}
+	}
+}
+
+func TestServeDirIsRelativeToCwd(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	ctx.cwd = t.TempDir()
+
+	if _, _, err := ctx.newRegistryHandler([]string{"--dir", "store"}); err != nil {
+		t.Fatalf("newRegistryHandler() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ctx.cwd, "store")); err != nil {
+		t.Errorf("Expected the store to be created in the working directory: %v", err)
+	}
+}
+
+func TestRemoteAndInstallCommands(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+
+	handler, _, err := ctx.newRegistryHandler([]string{"--dir", filepath.Join(ctx.projectDir, "store")})
+	if err != nil {
+		t.Fatalf("newRegistryHandler() error = %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/templates/node", strings.NewReader("node_modules/\n"))
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unable to publish template: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		command     func([]string) error
+		args        []string
+		wantErr     bool
+		expectedLen int
+	}{
+		{"list without remotes", ctx.commandRemote, []string{}, false, 2},
+		{"add remote", ctx.commandRemote, []string{"add", "team", server.URL}, false, 2},
+		{"add existing remote", ctx.commandRemote, []string{"add", "team", server.URL}, true, 2},
+		{"add remote without url", ctx.commandRemote, []string{"add", "other"}, true, 2},
+		{"list remotes", ctx.commandRemote, []string{"list"}, false, 2},
+		{"unknown subcommand", ctx.commandRemote, []string{"bogus"}, true, 2},
+		{"install template", ctx.commandInstall, []string{"team/node"}, false, 3},
+		{"install existing template", ctx.commandInstall, []string{"team/node"}, true, 3},
+		{"install existing template forced", ctx.commandInstall, []string{"team/node", "--force"}, false, 3},
+		{"install under another name", ctx.commandInstall, []string{"team/node", "--as", "js"}, false, 4},
+		{"install missing template", ctx.commandInstall, []string{"team/python"}, true, 4},
+		{"install from unknown remote", ctx.commandInstall, []string{"other/node"}, true, 4},
+		{"install without remote", ctx.commandInstall, []string{"node"}, true, 4},
+		{"remove remote", ctx.commandRemote, []string{"remove", "team"}, false, 4},
+		{"remove missing remote", ctx.commandRemote, []string{"remove", "team"}, true, 4},
+	}
 
-			err := ctx.commandCreate(tt.args)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.command(tt.args)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("commandCreate() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
 			}
-
 			if len(ctx.cfg.Templates) != tt.expectedLen {
 				t.Errorf("Expected templates to have length %d but got %d", tt.expectedLen, len(ctx.cfg.Templates))
 			}
-			if tt.baseChanged && ctx.cfg.Base != tt.args[0] {
-				t.Errorf("Expected base to be %s but got %s", tt.args[0], ctx.cfg.Base)
-			}
 		})
 	}
+
+	templ, err := config.FindTemplateByName(ctx.cfg, "js")
+	if err != nil {
+		t.Fatalf("Expected installed template js")
+	}
+	if templ.Source != server.URL+"/templates/node" {
+		t.Errorf("Expected source to be recorded but got %s", templ.Source)
+	}
 }
 
 func TestRenameCommand(t *testing.T) {
 	tests := []struct {
-		name         string
-		args         []string
-		wantErr      bool
-		pathChanged  bool
-		expectedBase string
+		name          string
+		args          []string
+		wantErr       bool
+		pathChanged   bool
+		expectedBase  string
+		expectedRules string
 	}{
-		{"no args", []string{}, true, false, "test1"},
-		{"missing args", []string{"test1"}, true, false, "test1"},
-		{"rename to existing template", []string{"test1", "test2"}, true, false, "test1"},
-		{"rename to new template", []string{"test1", "test3"}, false, true, "test3"},
-		{"rename to to a reserved word", []string{"test1", "help"}, true, false, "test1"},
+		{"no args", []string{}, true, false, "test1", "test1,test2"},
+		{"missing args", []string{"test1"}, true, false, "test1", "test1,test2"},
+		{"rename to existing template", []string{"test1", "test2"}, true, false, "test1", "test1,test2"},
+		{"rename to new template", []string{"test1", "test3"}, false, true, "test3", "test3,test2"},
+		{"rename to to a reserved word", []string{"test1", "help"}, true, false, "test1", "test1,test2"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cleanup := newTestContext(t)
 			defer cleanup()
+			ctx.cfg.BaseRules = []structs.BaseRule{{Path: "~/work", Template: "test1"}, {Path: "~/play", Template: "test2"}}
 
 			originalPath := ctx.cfg.Templates[0].Path
 			err := ctx.commandRename(tt.args)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("comandRename() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if rules := baseRuleTemplates(ctx); rules != tt.expectedRules {
+				t.Errorf("Expected base rules for %s but got %s", tt.expectedRules, rules)
+			}
 
 			if tt.pathChanged && ctx.cfg.Templates[0].Path == originalPath {
 				t.Errorf("Expected path to change but it did not")
@@ -210,26 +926,31 @@ func TestRenameCommand(t *testing.T) {
 
 func TestDeleteCommand(t *testing.T) {
 	tests := []struct {
-		name         string
-		args         []string
-		wantErr      bool
-		expectedBase string
-		expectedLen  int
+		name          string
+		args          []string
+		wantErr       bool
+		expectedBase  string
+		expectedLen   int
+		expectedRules string
 	}{
-		{"delete valid", []string{"test2", "--force"}, false, "test1", 1},
-		{"delete invalid", []string{"invalid", "--force"}, true, "test1", 2},
-		{"delete base", []string{"test1", "--force"}, false, "", 1},
+		{"delete valid", []string{"test2", "--force"}, false, "test1", 1, "test1"},
+		{"delete invalid", []string{"invalid", "--force"}, true, "test1", 2, "test1,test2"},
+		{"delete base", []string{"test1", "--force"}, false, "", 1, "test2"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cleanup := newTestContext(t)
 			defer cleanup()
+			ctx.cfg.BaseRules = []structs.BaseRule{{Path: "~/work", Template: "test1"}, {Path: "~/play", Template: "test2"}}
 
 			err := ctx.commandDelete(tt.args)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("commandDelete() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if rules := baseRuleTemplates(ctx); rules != tt.expectedRules {
+				t.Errorf("Expected base rules for %s but got %s", tt.expectedRules, rules)
+			}
 
 			if ctx.cfg.Base != tt.expectedBase {
 				t.Errorf("Expected base to be %s but got %s", tt.expectedBase, ctx.cfg.Base)
@@ -242,6 +963,15 @@ func TestDeleteCommand(t *testing.T) {
 	}
 }
 
+// baseRuleTemplates joins the templates of the base rules in order
+func baseRuleTemplates(ctx *Context) string {
+	var names []string
+	for _, rule := range ctx.cfg.BaseRules {
+		names = append(names, rule.Template)
+	}
+	return strings.Join(names, ",")
+}
+
 func TestEditCommand(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -312,6 +1042,11 @@ func TestCommandGenerate(t *testing.T) {
 		{"generate valid", []string{"test2", "--force"}, false, false},
 		{"generate invalid", []string{"invalid", "--force"}, false, true},
 		{"generate valid with gitignore", []string{"test2", "--force"}, true, false},
+		{"generate multiple", []string{"test1", "test2", "--force"}, false, false},
+		{"generate multiple with invalid", []string{"test1", "invalid", "--force"}, false, true},
+		{"generate no args", []string{"--force"}, false, true},
+		{"generate ignores case", []string{"TEST2", "--force"}, false, false},
+		{"generate merged", []string{"test1", "test2", "--force", "--merge"}, false, false},
 	}
 
 	for _, tt := range tests {
@@ -329,6 +1064,526 @@ func TestCommandGenerate(t *testing.T) {
 	}
 }
 
+func TestDetectCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		markers       []string
+		args          []string
+		wantErr       bool
+		wantGenerated bool
+	}{
+		{"nothing detected", nil, []string{"--auto"}, false, false},
+		{"suggest only", []string{"go.mod"}, []string{}, false, false},
+		{"auto generate", []string{"go.mod"}, []string{"--auto"}, false, true},
+		{"auto without installed templates", []string{"Cargo.toml"}, []string{"--auto"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			ctx.cfg.Detect = []structs.DetectRule{{Marker: "go.mod", Templates: []string{"test2"}}}
+			writeTemplate(t, ctx, "test2", "*.exe\n")
+			for _, marker := range tt.markers {
+				if err := os.WriteFile(filepath.Join(ctx.cwd, marker), nil, 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", marker, err)
+				}
+			}
+
+			err := ctx.commandDetect(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("commandDetect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			content, _ := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+			generated := strings.Contains(string(content), "*.exe")
+			if generated != tt.wantGenerated {
+				t.Errorf("Expected generated %v but got %v", tt.wantGenerated, generated)
+			}
+		})
+	}
+}
+
+func TestTemplateVariables(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	writeTemplate(t, ctx, "test2", "{{ .BuildDir }}/\n{{ .ProjectName }}.db\n")
+
+	if err := ctx.commandVars([]string{"test2", "BuildDir=dist", "Unused=x"}); err != nil {
+		t.Fatalf("commandVars() error = %v", err)
+	}
+	if err := ctx.commandVars([]string{"test2", "Unused="}); err != nil {
+		t.Fatalf("commandVars() error = %v", err)
+	}
+	templ, _ := config.FindTemplateByName(ctx.cfg, "test2")
+	if len(templ.Vars) != 1 || templ.Vars["BuildDir"] != "dist" {
+		t.Errorf("Expected the BuildDir default only but got %v", templ.Vars)
+	}
+	if err := ctx.commandVars([]string{"test2"}); err != nil {
+		t.Errorf("commandVars() error = %v", err)
+	}
+	if err := ctx.commandVars([]string{"test2", "=x"}); err == nil {
+		t.Errorf("Expected an error for a pair without a name")
+	}
+
+	if err := ctx.commandGenerate([]string{"test2", "--force"}); err == nil {
+		t.Errorf("Expected an error for the missing ProjectName")
+	}
+	if err := ctx.commandGenerate([]string{"test2", "--set", "ProjectName=app", "--force"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+	if !strings.Contains(string(content), "dist/\napp.db\n") {
+		t.Errorf("Expected rendered variables but got %q", string(content))
+	}
+}
+
+func TestGenerateWithIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		test1    string
+		test2    string
+		wantErr  bool
+		expected string
+	}{
+		{"include", "*.log\n", "#gogi:include test1\nbin/\n", false, "# >>> gogi:test2\n*.log\nbin/\n# <<< gogi:test2\n"},
+		{"extends and drops", "*.log\n.env\n", "#gogi:extends TEST1\n#gogi:drop .env\n", false, "# >>> gogi:test2\n*.log\n# <<< gogi:test2\n"},
+		{"cycle", "#gogi:include test2\n", "#gogi:include test1\n", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			for name, content := range map[string]string{"test1": tt.test1, "test2": tt.test2} {
+				writeTemplate(t, ctx, name, content)
+			}
+			err := ctx.commandGenerate([]string{"test2", "--force"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commandGenerate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			content, err := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, string(content))
+			}
+		})
+	}
+}
+
+func TestStatusCommand(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	writeTemplate(t, ctx, "test1", "*.log\n")
+	writeTemplate(t, ctx, "test2", "*.exe\n")
+
+	if err := ctx.commandGenerate([]string{"test1", "--force"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	if err := ctx.commandStatus(nil); err == nil {
+		t.Errorf("Expected an error for a file without a header")
+	}
+
+	if err := ctx.commandGenerate([]string{"test1", "--force", "--header"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	if err := ctx.commandStatus(nil); err != nil {
+		t.Errorf("Expected a freshly generated file to be up to date but got %v", err)
+	}
+
+	if err := ctx.commandAppend([]string{"test2"}); err != nil {
+		t.Fatalf("commandAppend() error = %v", err)
+	}
+	if err := ctx.commandStatus(nil); err != nil {
+		t.Errorf("Expected an appended file to be up to date but got %v", err)
+	}
+
+	writeTemplate(t, ctx, "test2", "*.exe\n*.dll\n")
+	changed, err := ctx.changedTemplates(readProvenance(t, ctx))
+	if err != nil || strings.Join(changed, ",") != "test2" {
+		t.Errorf("Expected test2 to have changed but got %v, %v", changed, err)
+	}
+	if err := ctx.commandStatus(nil); err == nil {
+		t.Errorf("Expected a changed template to be reported as drift")
+	}
+
+	if err := ctx.commandAppend([]string{"test2"}); err != nil {
+		t.Fatalf("commandAppend() error = %v", err)
+	}
+	gitignorePath := filepath.Join(ctx.cwd, ".gitignore")
+	content, _ := os.ReadFile(gitignorePath)
+	if err := os.WriteFile(gitignorePath, append(content, "local/\n"...), 0644); err != nil {
+		t.Fatalf("Failed to edit .gitignore: %v", err)
+	}
+	if err := ctx.commandStatus(nil); err == nil {
+		t.Errorf("Expected an edited file to be reported as drift")
+	}
+
+	// in merge mode a block leaves out the patterns of the blocks around
+	// it, as gogi update writes it
+	writeTemplate(t, ctx, "test1", "*.log\n")
+	writeTemplate(t, ctx, "test2", "*.exe\n")
+	if err := ctx.commandGenerate([]string{"test1", "test2", "--force", "--header", "--merge", "--record"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	writeTemplate(t, ctx, "test1", "*.log\n*.exe\n")
+	if err := ctx.commandUpdate(nil); err != nil {
+		t.Fatalf("commandUpdate() error = %v", err)
+	}
+	changed, err = ctx.changedTemplates(readProvenance(t, ctx))
+	if err != nil || len(changed) > 0 {
+		t.Errorf("Expected no changed templates after an update in merge mode but got %v, %v", changed, err)
+	}
+	if err := ctx.commandStatus(nil); err != nil {
+		t.Errorf("Expected an updated file to be up to date but got %v", err)
+	}
+}
+
+func readProvenance(t *testing.T, ctx *Context) (generator.Provenance, []byte, generator.Options) {
+	t.Helper()
+	content, err := generator.ReadGitignore(ctx.cwd)
+	if err != nil {
+		t.Fatalf("ReadGitignore() error = %v", err)
+	}
+	p, body, _, err := generator.ParseProvenance(content)
+	if err != nil {
+		t.Fatalf("ParseProvenance() error = %v", err)
+	}
+	return p, body, generator.Options{Load: ctx.loadTemplate}
+}
+
+func TestDiffCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		gitignore    string
+		args         []string
+		expectedCode int
+	}{
+		{"no gitignore", "", []string{"test1"}, diffDiffer},
+		{"same as generated", "# >>> gogi:test1\n*.log\n.env\n# <<< gogi:test1\n", []string{"test1"}, diffSame},
+		{"defaults to base", "# >>> gogi:test1\n*.log\n.env\n# <<< gogi:test1\n", nil, diffSame},
+		{"differs", "*.log\n", []string{"test1"}, diffDiffer},
+		{"semantic ignores order and comments", "# mine\n.env\n\n*.log\n", []string{"test1", "--semantic"}, diffSame},
+		{"semantic differs", "*.log\n", []string{"test1", "--semantic"}, diffDiffer},
+		{"unknown template", "", []string{"invalid"}, diffError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			writeTemplate(t, ctx, "test1", "*.log\n.env\n")
+			if tt.gitignore != "" {
+				if err := os.WriteFile(filepath.Join(ctx.cwd, ".gitignore"), []byte(tt.gitignore), 0644); err != nil {
+					t.Fatalf("Failed to write .gitignore: %v", err)
+				}
+			}
+
+			err := ctx.commandDiff(tt.args)
+			code := diffSame
+			var exitErr *exitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.code
+			} else if err != nil {
+				t.Fatalf("Expected an exit code error but got %v", err)
+			}
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d but got %d", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestSyncCommand(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	for name, content := range map[string]string{"test1": "*.log\n", "test2": "{{ .BuildDir }}/\n"} {
+		writeTemplate(t, ctx, name, content)
+	}
+	writeManifest := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(ctx.cwd, ".gogi.yaml"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write manifest: %v", err)
+		}
+	}
+	gitignorePath := filepath.Join(ctx.cwd, ".gitignore")
+
+	if err := ctx.commandSync(nil); err == nil {
+		t.Errorf("Expected an error without a manifest")
+	}
+
+	writeManifest("templates: [test1, test2]\nvars:\n  BuildDir: out\npatterns:\n  - /data/\n")
+	if err := os.WriteFile(gitignorePath, []byte("mine/\n# >>> gogi:old\nold/\n# <<< gogi:old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	if err := ctx.commandSync([]string{"--check"}); err == nil {
+		t.Errorf("Expected the check to fail before syncing")
+	}
+	if err := ctx.commandSync(nil); err != nil {
+		t.Fatalf("commandSync() error = %v", err)
+	}
+	content, err := os.ReadFile(gitignorePath)
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+	expected := "mine/\n\n# >>> gogi:test1\n*.log\n# <<< gogi:test1\n\n# >>> gogi:test2\nout/\n# <<< gogi:test2\n" +
+		"\n# >>> gogi:.gogi.yaml\n/data/\n# <<< gogi:.gogi.yaml\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q but got %q", expected, string(content))
+	}
+	if err := ctx.commandSync([]string{"--check"}); err != nil {
+		t.Errorf("Expected the check to pass after syncing but got %v", err)
+	}
+
+	writeManifest("templates: [test1]\n")
+	if err := ctx.commandSync([]string{"--check"}); err == nil {
+		t.Errorf("Expected the check to fail after the manifest changed")
+	}
+	if err := ctx.commandSync(nil); err != nil {
+		t.Fatalf("commandSync() error = %v", err)
+	}
+	content, _ = os.ReadFile(gitignorePath)
+	if string(content) != "mine/\n\n# >>> gogi:test1\n*.log\n# <<< gogi:test1\n" {
+		t.Errorf("Expected the dropped blocks to be removed but got %q", string(content))
+	}
+}
+
+func TestSyncIsReproducible(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	root := ctx.cwd
+	ctx.cfg.Detect = []structs.DetectRule{{Marker: "go.mod", Templates: []string{"go"}}}
+	writeTemplate(t, ctx, "test1", "*.log\n#gogi:if stack=go\nvendor/\n#gogi:endif\n")
+	writeTemplate(t, ctx, "test2", "#gogi:if env=CI\nci/\n#gogi:endif\n")
+	for name, content := range map[string]string{"go.mod": "module example\n", ".gogi.yaml": "templates: [test1]\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// the stack is detected next to the manifest, not in the working directory
+	ctx.cwd = filepath.Join(root, "sub")
+	if err := os.MkdirAll(ctx.cwd, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := ctx.commandSync(nil); err != nil {
+		t.Fatalf("commandSync() error = %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if !strings.Contains(string(content), "vendor/\n") {
+		t.Errorf("Expected the stack of the manifest directory to be used but got %q", string(content))
+	}
+
+	opts := manifestOptions(&manifest.Manifest{}, generator.Options{})
+	if opts.Target.OS != manifest.DefaultOS || opts.Target.Arch != manifest.DefaultArch || !opts.Target.NoEnv {
+		t.Errorf("Expected a fixed platform without the environment but got %+v", opts.Target)
+	}
+	opts = manifestOptions(&manifest.Manifest{OS: "darwin"}, generator.Options{Target: generator.Target{Arch: "arm64"}})
+	if opts.Target.OS != "darwin" || opts.Target.Arch != "arm64" {
+		t.Errorf("Expected the manifest and the flags to pick the platform but got %+v", opts.Target)
+	}
+
+	t.Setenv("CI", "true")
+	if err := os.WriteFile(filepath.Join(root, ".gogi.yaml"), []byte("templates: [test2]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := ctx.commandSync([]string{"--check"}); err == nil {
+		t.Errorf("Expected an env= condition to be rejected by sync")
+	}
+}
+
+func TestLockCommand(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	writeTemplate(t, ctx, "test1", "*.log\n#gogi:include test2\n")
+	writeTemplate(t, ctx, "test2", "*.exe\n")
+
+	if err := ctx.commandVerify(nil); err == nil {
+		t.Errorf("Expected an error without a lockfile")
+	}
+	if err := ctx.commandGenerate([]string{"test1", "--force"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	if _, err := os.Stat(lock.Path(ctx.cwd)); !os.IsNotExist(err) {
+		t.Errorf("Expected no lockfile without --lock but got %v", err)
+	}
+
+	if err := ctx.commandGenerate([]string{"test1", "--force", "--lock"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	l, err := lock.Load(lock.Path(ctx.cwd))
+	if err != nil {
+		t.Fatalf("lock.Load() error = %v", err)
+	}
+	if len(l.Templates) != 2 || l.Templates[0].Name != "test1" || l.Templates[1].Name != "test2" {
+		t.Errorf("Expected test1 and its include test2 to be locked but got %v", l.Templates)
+	}
+	if err := ctx.commandVerify(nil); err != nil {
+		t.Errorf("Expected the locked templates to verify but got %v", err)
+	}
+
+	writeTemplate(t, ctx, "test2", "*.exe\n*.dll\n")
+	if err := ctx.commandVerify(nil); err == nil {
+		t.Errorf("Expected verify to report the changed include")
+	}
+	if err := ctx.commandGenerate([]string{"test1", "--force"}); err == nil {
+		t.Errorf("Expected generate to refuse a changed template")
+	}
+	if err := ctx.commandGenerate([]string{"test1", "--force", "--update"}); err != nil {
+		t.Fatalf("commandGenerate() with --update error = %v", err)
+	}
+	if err := ctx.commandVerify(nil); err != nil {
+		t.Errorf("Expected the updated lock to verify but got %v", err)
+	}
+
+	writeTemplate(t, ctx, "test3", "*.tmp\n")
+	ctx.cfg.Templates = append(ctx.cfg.Templates, structs.Template{Name: "test3", Path: filepath.Join(ctx.projectDir, "test3.gitignore")})
+	if err := ctx.commandAppend([]string{"test3"}); err != nil {
+		t.Fatalf("commandAppend() error = %v", err)
+	}
+	l, err = lock.Load(lock.Path(ctx.cwd))
+	if err != nil {
+		t.Fatalf("lock.Load() error = %v", err)
+	}
+	if _, ok := l.Find("test3"); !ok || len(l.Templates) != 3 {
+		t.Errorf("Expected append to add test3 to the lock but got %v", l.Templates)
+	}
+}
+
+func TestTracksUpdates(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	if ctx.tracksUpdates() {
+		t.Errorf("Expected a project without lockfile or manifest not to be tracked")
+	}
+	if err := os.WriteFile(lock.Path(ctx.cwd), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	if !ctx.tracksUpdates() {
+		t.Errorf("Expected a project with a lockfile to be tracked")
+	}
+}
+
+func TestUpdateCommand(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	gitignorePath := filepath.Join(ctx.cwd, ".gitignore")
+	writeGitignore := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write .gitignore: %v", err)
+		}
+	}
+	writeTemplate(t, ctx, "test1", "*.log\n*.tmp\n")
+	writeTemplate(t, ctx, "test2", "*.exe\n")
+
+	if err := ctx.commandGenerate([]string{"test1", "--force"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	if _, err := os.Stat(generator.RecordPath(gitignorePath)); !os.IsNotExist(err) {
+		t.Errorf("Expected no record without --record but got %v", err)
+	}
+	if err := ctx.commandGenerate([]string{"test1", "--force", "--record"}); err != nil {
+		t.Fatalf("commandGenerate() error = %v", err)
+	}
+	if err := ctx.commandAppend([]string{"test2"}); err != nil {
+		t.Fatalf("commandAppend() error = %v", err)
+	}
+	generated, _ := os.ReadFile(gitignorePath)
+	writeGitignore(string(generated) + "\n/local/\n")
+
+	writeTemplate(t, ctx, "test1", "*.log\n*.out\n")
+	if err := ctx.commandUpdate([]string{"--dry-run"}); err != nil {
+		t.Fatalf("commandUpdate() with --dry-run error = %v", err)
+	}
+	if content, _ := os.ReadFile(gitignorePath); strings.Contains(string(content), "*.out") {
+		t.Errorf("Expected --dry-run to leave .gitignore alone but got\n%s", content)
+	}
+
+	if err := ctx.commandUpdate(nil); err != nil {
+		t.Fatalf("commandUpdate() error = %v", err)
+	}
+	expected := "# >>> gogi:test1\n*.log\n*.out\n# <<< gogi:test1\n\n# >>> gogi:test2\n*.exe\n# <<< gogi:test2\n\n/local/\n"
+	if content, _ := os.ReadFile(gitignorePath); string(content) != expected {
+		t.Errorf("Expected the update to keep the local line\n%s\nbut got\n%s", expected, content)
+	}
+
+	content, _ := os.ReadFile(gitignorePath)
+	writeGitignore(strings.Replace(string(content), "*.exe", "*.bin", 1))
+	writeTemplate(t, ctx, "test2", "*.dll\n")
+	if err := ctx.commandUpdate(nil); err == nil {
+		t.Errorf("Expected an error for conflicting changes")
+	}
+	content, _ = os.ReadFile(gitignorePath)
+	for _, marker := range []string{"<<<<<<< .gitignore\n*.bin\n", "||||||| last generated\n*.exe\n", "=======\n*.dll\n>>>>>>> templates\n"} {
+		if !strings.Contains(string(content), marker) {
+			t.Errorf("Expected the conflict to contain %q but got\n%s", marker, content)
+		}
+	}
+
+	if err := ctx.commandUpdate(nil); err == nil || !strings.Contains(err.Error(), "unresolved conflict") {
+		t.Errorf("Expected a second update to refuse the unresolved conflict but got %v", err)
+	}
+	if err := ctx.commandLint([]string{"--project"}); err == nil {
+		t.Errorf("Expected lint to report the conflict markers")
+	}
+	content, _ = os.ReadFile(gitignorePath)
+	var resolved []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if !diff.IsConflictMarker(line) && line != "*.exe" && line != "*.dll" {
+			resolved = append(resolved, line)
+		}
+	}
+	writeGitignore(strings.Join(resolved, "\n"))
+	if err := ctx.commandUpdate(nil); err != nil {
+		t.Errorf("Expected an update after resolving the conflict to succeed but got %v", err)
+	}
+
+	if err := os.Remove(generator.RecordPath(gitignorePath)); err != nil {
+		t.Fatalf("Failed to remove the record: %v", err)
+	}
+	if err := ctx.commandUpdate(nil); err == nil {
+		t.Errorf("Expected an error without a record of the last generated file")
+	}
+}
+
+func TestGenerateForOS(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"darwin", []string{"test2", "--os", "macos", "--force"}, "# >>> gogi:test2\n.DS_Store\n# <<< gogi:test2\n"},
+		{"linux", []string{"test2", "--os", "linux", "--force"}, "# >>> gogi:test2\n*.so\n# <<< gogi:test2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cleanup := newTestContext(t)
+			defer cleanup()
+			templ := "#gogi:if os=darwin\n.DS_Store\n#gogi:else\n*.so\n#gogi:endif\n"
+			writeTemplate(t, ctx, "test2", templ)
+			if err := ctx.commandGenerate(tt.args); err != nil {
+				t.Fatalf("commandGenerate() error = %v", err)
+			}
+			content, err := os.ReadFile(filepath.Join(ctx.cwd, ".gitignore"))
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, string(content))
+			}
+		})
+	}
+}
+
 func TestCommandHelp(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -377,3 +1632,63 @@ func TestQuickGogi(t *testing.T) {
 		})
 	}
 }
+
+func TestQuickGogiWithLock(t *testing.T) {
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	gitignorePath := filepath.Join(ctx.cwd, ".gitignore")
+	quickGogi := func(args ...string) error {
+		t.Helper()
+		if unknown := ctx.unknownQuickArg(args); unknown != "" {
+			t.Fatalf("Expected %v to be routed to quick gogi but %q is unknown", args, unknown)
+		}
+		if err := os.RemoveAll(gitignorePath); err != nil {
+			t.Fatalf("Failed to remove .gitignore: %v", err)
+		}
+		return ctx.HandleQuickGogi(args...)
+	}
+	writeTemplate(t, ctx, "test2", "*.exe\n")
+
+	if err := quickGogi("test2", "--lock"); err != nil {
+		t.Fatalf("HandleQuickGogi() with --lock error = %v", err)
+	}
+	writeTemplate(t, ctx, "test2", "*.exe\n*.dll\n")
+	if err := quickGogi("test2"); err == nil {
+		t.Errorf("Expected quick gogi to refuse a changed locked template")
+	}
+	if err := quickGogi("test2", "--update"); err != nil {
+		t.Fatalf("HandleQuickGogi() with --update error = %v", err)
+	}
+	if content, _ := os.ReadFile(gitignorePath); !strings.Contains(string(content), "*.dll") {
+		t.Errorf("Expected the changed template to be used but got\n%s", content)
+	}
+	if err := ctx.commandVerify(nil); err != nil {
+		t.Errorf("Expected --update to update the lock but got %v", err)
+	}
+}
+
+func TestUnknownQuickArg(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"template names", []string{"test1", "test2"}, ""},
+		{"leading flag", []string{"--update"}, ""},
+		{"trailing flags", []string{"test1", "--update", "--lock", "-m"}, ""},
+		{"flag values", []string{"--os", "linux", "test1", "--set", "k=v"}, ""},
+		{"unknown template", []string{"test1", "invalid"}, "invalid"},
+		{"unknown flag", []string{"test1", "--bogus"}, "--bogus"},
+		{"command flag", []string{"test1", "--force"}, "--force"},
+	}
+
+	ctx, cleanup := newTestContext(t)
+	defer cleanup()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if unknown := ctx.unknownQuickArg(tt.args); unknown != tt.expected {
+				t.Errorf("Expected %q but got %q", tt.expected, unknown)
+			}
+		})
+	}
+}
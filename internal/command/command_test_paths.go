@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/ignore"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expectation is what the test command expects of every path
+type expectation int
+
+const (
+	expectNothing expectation = iota
+	expectIgnored
+	expectNotIgnored
+)
+
+// met reports whether a verdict meets the expectation
+func (e expectation) met(ignored bool) bool {
+	switch e {
+	case expectIgnored:
+		return ignored
+	case expectNotIgnored:
+		return !ignored
+	}
+	return true
+}
+
+// commandTest is the callback for the "test" command
+// It checks paths against a rendered template and reports the line of the
+// rendered template that decided
+func (ctx *Context) commandTest(args []string) error {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	positional, flags := splitArgs(args)
+	if len(positional) == 0 {
+		return fmt.Errorf("no template name provided to test")
+	}
+	expect := expectNothing
+	switch {
+	case hasFlag(flags, "--expect-ignored") && hasFlag(flags, "--expect-not-ignored"):
+		return fmt.Errorf("--expect-ignored and --expect-not-ignored can not be combined")
+	case hasFlag(flags, "--expect-ignored"):
+		expect = expectIgnored
+	case hasFlag(flags, "--expect-not-ignored"):
+		expect = expectNotIgnored
+	}
+
+	templ, err := config.FindTemplateByName(ctx.cfg, positional[0])
+	if err != nil {
+		return fmt.Errorf("could not find template '%s'", positional[0])
+	}
+	content, err := generator.ReadTemplate(*templ, opts)
+	if err != nil {
+		return err
+	}
+	file := ignore.NewFile(templ.Path, "", content)
+
+	paths := positional[1:]
+	if len(paths) == 0 {
+		if paths, err = readPathsFromStdin(); err != nil {
+			return err
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths provided to test")
+	}
+
+	failed := ctx.testPaths(ignore.NewMatcher(file), templ.Name, paths, expect, os.Stdout)
+	if failed > 0 {
+		return fmt.Errorf("%d path(s) did not meet the expectation", failed)
+	}
+	return nil
+}
+
+// testPaths writes the verdict of the matcher for every path to out and
+// returns how many verdicts did not meet the expectation
+func (ctx *Context) testPaths(m *ignore.Matcher, templName string, paths []string, expect expectation, out io.Writer) int {
+	failures := 0
+	for _, p := range paths {
+		match := m.Match(filepath.ToSlash(p), ctx.isDir(p))
+
+		verdict := "not ignored"
+		if match.Ignored {
+			verdict = "ignored"
+		}
+		if !expect.met(match.Ignored) {
+			verdict = "FAIL " + verdict
+			failures++
+		}
+
+		if match.Pattern == nil {
+			fmt.Fprintf(out, "%s: %s\n", p, verdict)
+			continue
+		}
+		fmt.Fprintf(out, "%s: %s by %s:%d '%s'\n", p, verdict, templName, match.Pattern.Line, match.Pattern.Raw)
+	}
+	return failures
+}
+
+// isDir reports whether the path is a directory, either marked by a
+// trailing slash or as found relative to the working directory
+func (ctx *Context) isDir(p string) bool {
+	if strings.HasSuffix(p, "/") {
+		return true
+	}
+	info, err := os.Stat(ctx.resolvePath(p))
+	return err == nil && info.IsDir()
+}
+
+// readPathsFromStdin reads one path per line when paths are piped in
+func readPathsFromStdin() ([]string, error) {
+	if isTerminal(os.Stdin) {
+		return nil, nil
+	}
+	return readPaths(os.Stdin)
+}
+
+// readPaths reads one path per line, skipping blank lines
+func readPaths(in io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+	return paths, nil
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/diff"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/lock"
+	"github.com/SQUASHD/gogi/internal/manifest"
+	"github.com/SQUASHD/gogi/internal/structs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commandUpdate is the callback for the "update" command
+// It brings the templates of the project .gitignore up to date with a
+// three-way merge between the content gogi last generated, the current
+// file and the content the templates generate now, so lines added by hand
+// are kept
+func (ctx *Context) commandUpdate(args []string) error {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	_, flags := splitArgs(args)
+	dryRun := hasFlag(flags, "--dry-run", "-n")
+
+	giPath := filepath.Join(ctx.cwd, ".gitignore")
+	current, err := generator.ReadGitignore(ctx.cwd)
+	if err != nil {
+		return err
+	}
+	last, err := generator.ReadRecord(giPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no record of the last generated .gitignore. regenerate it with gogi generate --record to start tracking it")
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the record of the last generated .gitignore: %w", err)
+	}
+
+	lastProvenance, lastBody, _, err := generator.ParseProvenance(last)
+	if err != nil {
+		return fmt.Errorf("could not read the gogi header of the last generated .gitignore: %w", err)
+	}
+	_, currentBody, hasHeader, err := generator.ParseProvenance(current)
+	if err != nil {
+		return fmt.Errorf("could not read the gogi header of .gitignore: %w", err)
+	}
+	for i, line := range generator.SplitLines(currentBody) {
+		if diff.IsConflictMarker(line) {
+			return fmt.Errorf(".gitignore has an unresolved conflict on line %d. resolve the lines between the %s and %s markers first", i+1, diff.MarkerOurs, diff.MarkerTheirs)
+		}
+	}
+	opts.Merge = opts.Merge || lastProvenance.Merge
+
+	templates, err := ctx.generatedTemplates(lastBody)
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		return fmt.Errorf("the last generated .gitignore has no installed templates to update")
+	}
+	locked, err := openLock(ctx.cwd, flags)
+	if err != nil {
+		return err
+	}
+	opts, err = locked.guard(templates, opts)
+	if err != nil {
+		return err
+	}
+
+	sections := make([]generator.Section, 0, len(templates))
+	names := make([]string, 0, len(templates))
+	for _, templ := range templates {
+		content, err := generator.ReadTemplate(templ, opts)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, generator.Section{Name: templ.Name, Content: content})
+		names = append(names, templ.Name)
+	}
+	// the blocks are replaced in the last generated content rather than
+	// composed anew, so a file built with append keeps its layout
+	updatedBody, _, err := generator.UpsertSections(lastBody, sections, opts)
+	if err != nil {
+		return err
+	}
+
+	mergedLines, conflicts := diff.Merge(generator.SplitLines(lastBody), generator.SplitLines(currentBody), generator.SplitLines(updatedBody),
+		".gitignore", "last generated", "templates")
+	mergedBody := generator.JoinLines(mergedLines)
+	if bytes.Equal(mergedBody, currentBody) {
+		fmt.Println(".gitignore is up to date with its templates")
+		return nil
+	}
+
+	merged, updated := mergedBody, updatedBody
+	if hasHeader || opts.Header {
+		provenance := generator.NewProvenance(names, opts.Merge)
+		merged = generator.WithProvenance(provenance, mergedBody)
+		updated = generator.WithProvenance(provenance, updatedBody)
+	}
+	if dryRun {
+		fmt.Print(string(merged))
+		if conflicts > 0 {
+			return fmt.Errorf("the update would have %d conflicts", conflicts)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(giPath, merged, 0644); err != nil {
+		return fmt.Errorf("unable to write to .gitignore file: %w", err)
+	}
+	if err := generator.WriteRecord(giPath, updated); err != nil {
+		return err
+	}
+	if err := locked.save(false); err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("updated .gitignore with %d conflicts. resolve the lines between the %s and %s markers", conflicts, diff.MarkerOurs, diff.MarkerTheirs)
+	}
+	fmt.Printf("updated .gitignore from template '%s'\n", strings.Join(names, "', '"))
+	return nil
+}
+
+// generatedTemplates returns the installed templates of the blocks of
+// generated content. Blocks of templates that are not installed, such as
+// the patterns of a project manifest, are left as they are.
+func (ctx *Context) generatedTemplates(content []byte) ([]structs.Template, error) {
+	blocks, err := generator.ParseBlocks(generator.SplitLines(content))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the blocks of the last generated .gitignore: %w", err)
+	}
+	var templates []structs.Template
+	for _, block := range blocks {
+		templ, err := config.FindTemplateByName(ctx.cfg, block.Name)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, *templ)
+	}
+	return templates, nil
+}
+
+// tracksUpdates reports whether the project in the working directory pins
+// its templates with a lockfile or manifest. Files generated there are
+// recorded for gogi update without --record.
+func (ctx *Context) tracksUpdates() bool {
+	for _, name := range []string{lock.FileName, manifest.FileName} {
+		if _, err := os.Stat(filepath.Join(ctx.cwd, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
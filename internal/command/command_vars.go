@@ -0,0 +1,193 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/config"
+	"github.com/SQUASHD/gogi/internal/detect"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// commandVars is the callback for the "vars" command
+// It lists the variables a template uses, or sets their default values
+// when given key=value pairs. An empty value removes the default.
+func (ctx *Context) commandVars(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no template name provided")
+	}
+	index, err := config.GetTemplateIndexByName(ctx.cfg, args[0])
+	if err != nil {
+		return fmt.Errorf("could not find template '%s'", args[0])
+	}
+	templ := ctx.cfg.Templates[index]
+
+	if len(args) == 1 {
+		content, err := os.ReadFile(templ.Path)
+		if err != nil {
+			return fmt.Errorf("unable to open template file: %w", err)
+		}
+		variables, err := generator.ListVariables(templ.Name, content)
+		if err != nil {
+			return err
+		}
+		if len(variables) == 0 && len(templ.Vars) == 0 {
+			fmt.Printf("template '%s' uses no variables\n", templ.Name)
+			return nil
+		}
+		used := make(map[string]bool)
+		for _, variable := range variables {
+			used[variable.Name] = true
+			if value, ok := templ.Vars[variable.Name]; ok {
+				fmt.Printf("%s = %s (line %d)\n", variable.Name, value, variable.Line)
+				continue
+			}
+			fmt.Printf("%s has no default (line %d)\n", variable.Name, variable.Line)
+		}
+		for _, name := range sortedKeys(templ.Vars) {
+			if !used[name] {
+				fmt.Printf("%s = %s (unused)\n", name, templ.Vars[name])
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range args[1:] {
+		name, value, err := parseVar(arg)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			delete(templ.Vars, name)
+			continue
+		}
+		if templ.Vars == nil {
+			templ.Vars = make(map[string]string)
+		}
+		templ.Vars[name] = value
+	}
+	if len(templ.Vars) == 0 {
+		templ.Vars = nil
+	}
+	if err := config.UpdateTemplate(ctx.cfg, templ, index); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(ctx.cfg, ctx.configPath); err != nil {
+		return err
+	}
+	fmt.Printf("updated the variables of template '%s'\n", templ.Name)
+	return nil
+}
+
+// generatorOptions takes the --set key=value pairs and the --os and
+// --arch flags out of args and returns the remaining arguments with the
+// options to render the templates with. Missing values are prompted for
+// on a terminal. The provenance header follows the configuration unless
+// --header or --no-header is given.
+func (ctx *Context) generatorOptions(args []string) ([]string, generator.Options, error) {
+	vars := make(map[string]string)
+	for {
+		pair, rest, found, err := takeFlagValue(args, "--set")
+		if err != nil {
+			return nil, generator.Options{}, err
+		}
+		if !found {
+			break
+		}
+		name, value, err := parseVar(pair)
+		if err != nil {
+			return nil, generator.Options{}, err
+		}
+		vars[name] = value
+		args = rest
+	}
+
+	targetOS, args, _, err := takeFlagValue(args, "--os")
+	if err != nil {
+		return nil, generator.Options{}, err
+	}
+	targetArch, args, _, err := takeFlagValue(args, "--arch")
+	if err != nil {
+		return nil, generator.Options{}, err
+	}
+
+	_, flags := splitArgs(args)
+	opts := generator.Options{
+		Merge: hasFlag(flags, "--merge", "-m"),
+		Vars:  vars,
+		Target: generator.Target{
+			OS:    targetOS,
+			Arch:  targetArch,
+			Stack: ctx.detectStack(ctx.cwd),
+		},
+		Load:   ctx.loadTemplate,
+		Header: (ctx.cfg.Header || hasFlag(flags, "--header")) && !hasFlag(flags, "--no-header"),
+		Record: hasFlag(flags, "--record") || ctx.tracksUpdates(),
+	}
+	if isTerminal(os.Stdin) {
+		opts.Prompt = promptVariables(os.Stdin, os.Stdout)
+	}
+	return args, opts, nil
+}
+
+// detectStack returns the template names detected for the project in
+// dir, which #gogi:if stack=name conditions test against
+func (ctx *Context) detectStack(dir string) []string {
+	matches, err := detect.Detect(dir, detect.Rules(ctx.cfg.Detect))
+	if err != nil {
+		return nil
+	}
+	return detect.Templates(matches)
+}
+
+// parseVar splits a key=value pair
+func parseVar(pair string) (string, string, error) {
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid variable '%s'. use key=value", pair)
+	}
+	return name, value, nil
+}
+
+// promptVariables returns a prompt asking for the value of each variable
+// once, reusing the answer for every template that uses the variable
+func promptVariables(in io.Reader, out io.Writer) func(template, name string) (string, error) {
+	reader := bufio.NewReader(in)
+	answers := make(map[string]string)
+	return func(template, name string) (string, error) {
+		if value, ok := answers[name]; ok {
+			return value, nil
+		}
+		if _, err := fmt.Fprintf(out, "Value for %s (used by template '%s'): ", name, template); err != nil {
+			return "", fmt.Errorf("error writing to output: %w", err)
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("error reading input: %w", err)
+		}
+		answers[name] = strings.TrimSpace(line)
+		return answers[name], nil
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// sortedKeys returns the keys of m in alphabetical order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
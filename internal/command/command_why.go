@@ -0,0 +1,103 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/ignore"
+	"io"
+	"os"
+	"strings"
+)
+
+// commandWhy is the callback for the "why" command
+// It explains which rule, file and template decide whether a path is ignored
+func (ctx *Context) commandWhy(args []string) error {
+	args, opts, err := ctx.generatorOptions(args)
+	if err != nil {
+		return err
+	}
+	positional, _ := splitArgs(args)
+	if len(positional) != 1 {
+		return fmt.Errorf("expected exactly one path to explain")
+	}
+	return ctx.explainPath(os.Stdout, positional[0], opts)
+}
+
+// explainPath writes to w whether target is ignored and which rule, file
+// and template decide it. Relative targets are taken from the working
+// directory, and templates are rendered with opts before they are compared.
+func (ctx *Context) explainPath(w io.Writer, target string, opts generator.Options) error {
+	root, err := ignore.FindRoot(ctx.cwd)
+	if err != nil {
+		return err
+	}
+	rel, err := ignore.RelativePath(root, ctx.resolvePath(target))
+	if err != nil {
+		return err
+	}
+	files, err := ignore.RepositoryFiles(root, rel)
+	if err != nil {
+		return err
+	}
+
+	match := ignore.NewMatcher(files...).Match(rel, ctx.isDir(target))
+	if match.Pattern == nil {
+		fmt.Fprintf(w, "%s is not ignored: no pattern matches it\n", target)
+		return nil
+	}
+
+	if match.Ignored {
+		fmt.Fprintf(w, "%s is ignored\n", target)
+	} else {
+		fmt.Fprintf(w, "%s is not ignored: a negated pattern re-includes it\n", target)
+	}
+	if match.Path != strings.TrimSuffix(rel, "/") {
+		fmt.Fprintf(w, "  parent:   %s/ is excluded, so git never looks inside it\n", match.Path)
+	}
+	fmt.Fprintf(w, "  pattern:  %s\n", strings.TrimSpace(match.Pattern.Raw))
+	fmt.Fprintf(w, "  source:   %s:%d\n", displaySource(root, match.File.Source), match.Pattern.Line)
+	if templName, how := ctx.attributeTemplate(match, opts); templName != "" {
+		fmt.Fprintf(w, "  template: %s (%s)\n", templName, how)
+	}
+	return nil
+}
+
+// attributeTemplate finds the gogi template the deciding line most likely
+// came from: the template whose block holds the line, or else the first
+// installed template whose rendered output has an equivalent pattern.
+// Templates that can not be rendered without prompting are skipped.
+func (ctx *Context) attributeTemplate(match ignore.Match, opts generator.Options) (string, string) {
+	opts.Prompt = nil
+	if content, err := os.ReadFile(match.File.Source); err == nil {
+		lines := strings.Split(string(content), "\n")
+		if blocks, err := generator.ParseBlocks(lines); err == nil {
+			for _, block := range blocks {
+				if match.Pattern.Line-1 > block.Start && match.Pattern.Line-1 < block.End {
+					return block.Name, "written by gogi"
+				}
+			}
+		}
+	}
+
+	key := match.Pattern.Key()
+	for _, templ := range ctx.cfg.Templates {
+		content, err := generator.ReadTemplate(templ, opts)
+		if err != nil {
+			continue
+		}
+		for _, p := range ignore.Parse(content) {
+			if p.Key() == key {
+				return templ.Name, fmt.Sprintf("same pattern on line %d of the rendered template", p.Line)
+			}
+		}
+	}
+	return "", ""
+}
+
+// displaySource shows files inside the repository relative to its root
+func displaySource(root, source string) string {
+	if rel, err := ignore.RelativePath(root, source); err == nil {
+		return rel
+	}
+	return source
+}
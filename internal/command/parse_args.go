@@ -0,0 +1,50 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitArgs separates the positional arguments from the flags, keeping
+// the order in which each of them was given
+func splitArgs(args []string) (positional, flags []string) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			flags = append(flags, arg)
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return positional, flags
+}
+
+// hasFlag reports whether any of the given names is among the flags
+func hasFlag(flags []string, names ...string) bool {
+	for _, flag := range flags {
+		for _, name := range names {
+			if flag == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// takeFlagValue removes the first of the named flags and the value that
+// follows it from args. It reports whether the flag was given and fails
+// when the flag has no value.
+func takeFlagValue(args []string, names ...string) (string, []string, bool, error) {
+	for i, arg := range args {
+		for _, name := range names {
+			if arg != name {
+				continue
+			}
+			if i+1 == len(args) {
+				return "", args, true, fmt.Errorf("%s requires a value", name)
+			}
+			rest := append(append([]string{}, args[:i]...), args[i+2:]...)
+			return args[i+1], rest, true, nil
+		}
+	}
+	return "", args, false, nil
+}
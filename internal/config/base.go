@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// ResolveBase returns the base template for dir and the index of the
+// base rule it came from. The first rule matching dir wins, and the global
+// base is used with an index of -1 when none does.
+func ResolveBase(cfg *structs.TemplateConfig, dir string) (string, int) {
+	for i, rule := range cfg.BaseRules {
+		if MatchBaseRule(rule, dir) {
+			return rule.Template, i
+		}
+	}
+	return cfg.Base, -1
+}
+
+// MatchBaseRule reports whether the rule applies to dir. A rule path
+// containing glob characters matches dir or any of its parents, otherwise
+// it matches the directory itself and everything below it. A leading ~ is
+// expanded to the home directory.
+func MatchBaseRule(rule structs.BaseRule, dir string) bool {
+	pattern := filepath.Clean(ExpandHome(rule.Path))
+	dir = filepath.Clean(dir)
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return dir == pattern || strings.HasPrefix(dir, strings.TrimSuffix(pattern, string(filepath.Separator))+string(filepath.Separator))
+	}
+	for {
+		if ok, err := filepath.Match(pattern, dir); err == nil && ok {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// ExpandHome replaces a leading ~ in path with the home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
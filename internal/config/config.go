@@ -3,12 +3,16 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
+
 	goconfig "github.com/SQUASHD/go-config/config"
 	"github.com/SQUASHD/gogi/internal/structs"
 )
 
 var ErrTemplateNotFound = errors.New("template not found")
 
+var ErrBundleNotFound = errors.New("bundle not found")
+
 func InitConfig(configPath string) error {
 	var cfg structs.TemplateConfig
 	if err := goconfig.InitConfig(configPath, cfg); err != nil {
@@ -32,18 +36,21 @@ func SaveConfig(cfg *structs.TemplateConfig, configPath string) error {
 	return nil
 }
 
+// FindTemplateByName looks up a template, ignoring the case of its name
 func FindTemplateByName(cfg *structs.TemplateConfig, name string) (*structs.Template, error) {
 	for _, tmpl := range cfg.Templates {
-		if tmpl.Name == name {
+		if strings.EqualFold(tmpl.Name, name) {
 			return &tmpl, nil
 		}
 	}
 	return nil, ErrTemplateNotFound
 }
 
+// GetTemplateIndexByName looks up the index of a template, ignoring the
+// case of its name
 func GetTemplateIndexByName(cfg *structs.TemplateConfig, name string) (int, error) {
 	for i, tmpl := range cfg.Templates {
-		if tmpl.Name == name {
+		if strings.EqualFold(tmpl.Name, name) {
 			return i, nil
 		}
 	}
@@ -59,3 +66,23 @@ func UpdateTemplate(cfg *structs.TemplateConfig, tmpl structs.Template, index in
 	cfg.Templates[index] = tmpl
 	return nil
 }
+
+// FindBundleByName looks up a bundle, ignoring the case of its name
+func FindBundleByName(cfg *structs.TemplateConfig, name string) (*structs.Bundle, error) {
+	i, err := GetBundleIndexByName(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Bundles[i], nil
+}
+
+// GetBundleIndexByName looks up the index of a bundle, ignoring the case
+// of its name
+func GetBundleIndexByName(cfg *structs.TemplateConfig, name string) (int, error) {
+	for i, bundle := range cfg.Bundles {
+		if strings.EqualFold(bundle.Name, name) {
+			return i, nil
+		}
+	}
+	return -1, ErrBundleNotFound
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// SharedFileName is the file of the template directory holding the part
+// of the configuration that is synced across machines
+const SharedFileName = "templates.json"
+
+// Shared is the part of the configuration synced with the templates.
+// Template paths are relative to the template directory.
+type Shared struct {
+	Templates []structs.Template   `json:"templates"`
+	Bundles   []structs.Bundle     `json:"bundles"`
+	Sources   []structs.Source     `json:"sources"`
+	Detect    []structs.DetectRule `json:"detect"`
+}
+
+// WriteShared writes the shared part of cfg to the template directory dir
+func WriteShared(cfg *structs.TemplateConfig, dir string) error {
+	shared := Shared{
+		Templates: make([]structs.Template, 0, len(cfg.Templates)),
+		Bundles:   cfg.Bundles,
+		Sources:   cfg.Sources,
+		Detect:    cfg.Detect,
+	}
+	for _, templ := range cfg.Templates {
+		if rel, err := filepath.Rel(dir, templ.Path); err == nil && filepath.IsLocal(rel) {
+			templ.Path = filepath.ToSlash(rel)
+		}
+		shared.Templates = append(shared.Templates, templ)
+	}
+	content, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, SharedFileName), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", SharedFileName, err)
+	}
+	return nil
+}
+
+// ReadShared returns the shared configuration of the template directory
+// dir, or nil when it has none
+func ReadShared(dir string) (*Shared, error) {
+	content, err := os.ReadFile(filepath.Join(dir, SharedFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", SharedFileName, err)
+	}
+	var shared Shared
+	if err := json.Unmarshal(content, &shared); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SharedFileName, err)
+	}
+	for i, templ := range shared.Templates {
+		if !filepath.IsAbs(templ.Path) {
+			shared.Templates[i].Path = filepath.Join(dir, filepath.FromSlash(templ.Path))
+		}
+	}
+	return &shared, nil
+}
+
+// ApplyShared merges the shared configuration in the template directory
+// dir into cfg. base is the shared configuration cfg was last synced
+// with, nil when there was none. Entries pulled replace those of cfg with
+// the same name, entries added to cfg since base are kept and entries
+// removed from cfg since base stay removed. It reports whether dir had a
+// shared configuration.
+func ApplyShared(cfg *structs.TemplateConfig, dir string, base *Shared) (bool, error) {
+	pulled, err := ReadShared(dir)
+	if err != nil || pulled == nil {
+		return false, err
+	}
+	if base == nil {
+		base = &Shared{}
+	}
+	cfg.Templates = mergeEntries(base.Templates, cfg.Templates, pulled.Templates,
+		func(t structs.Template) string { return t.Name })
+	cfg.Bundles = mergeEntries(base.Bundles, cfg.Bundles, pulled.Bundles,
+		func(b structs.Bundle) string { return b.Name })
+	cfg.Sources = mergeEntries(base.Sources, cfg.Sources, pulled.Sources,
+		func(s structs.Source) string { return s.Name })
+	cfg.Detect = mergeEntries(base.Detect, cfg.Detect, pulled.Detect,
+		func(r structs.DetectRule) string { return r.Marker })
+	return true, nil
+}
+
+// mergeEntries merges the local and pulled versions of a list that both
+// started out as base. Names are compared ignoring case.
+func mergeEntries[T any](base, local, pulled []T, name func(T) string) []T {
+	names := func(entries []T) map[string]bool {
+		set := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			set[strings.ToLower(name(entry))] = true
+		}
+		return set
+	}
+	inBase, inLocal, inPulled := names(base), names(local), names(pulled)
+
+	merged := make([]T, 0, len(pulled)+len(local))
+	for _, entry := range pulled {
+		key := strings.ToLower(name(entry))
+		if inBase[key] && !inLocal[key] {
+			continue
+		}
+		merged = append(merged, entry)
+	}
+	for _, entry := range local {
+		key := strings.ToLower(name(entry))
+		if !inBase[key] && !inPulled[key] {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
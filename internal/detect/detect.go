@@ -0,0 +1,97 @@
+package detect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// DefaultRules are the markers gogi recognises out of the box. The
+// template names follow the file names of the github/gitignore collection
+// as imported by gogi import-collection.
+var DefaultRules = []structs.DetectRule{
+	{Marker: "go.mod", Templates: []string{"go"}},
+	{Marker: "package.json", Templates: []string{"node"}},
+	{Marker: "Cargo.toml", Templates: []string{"rust"}},
+	{Marker: "pyproject.toml", Templates: []string{"python"}},
+	{Marker: "requirements.txt", Templates: []string{"python"}},
+	{Marker: "setup.py", Templates: []string{"python"}},
+	{Marker: "pom.xml", Templates: []string{"maven"}},
+	{Marker: "build.gradle", Templates: []string{"gradle"}},
+	{Marker: "build.gradle.kts", Templates: []string{"gradle"}},
+	{Marker: "*.csproj", Templates: []string{"visualstudio"}},
+	{Marker: "*.sln", Templates: []string{"visualstudio"}},
+	{Marker: "*.tf", Templates: []string{"terraform"}},
+}
+
+// Match is a rule whose marker was found in the inspected directory
+type Match struct {
+	Rule structs.DetectRule
+	// Files are the names of the files matching the marker
+	Files []string
+}
+
+// Rules returns the user rules followed by the default rules. A user rule
+// replaces the default rule for the same marker.
+func Rules(userRules []structs.DetectRule) []structs.DetectRule {
+	rules := append([]structs.DetectRule{}, userRules...)
+	for _, rule := range DefaultRules {
+		overridden := false
+		for _, userRule := range userRules {
+			if userRule.Marker == rule.Marker {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Detect inspects the files at the top level of dir and returns the rules
+// whose marker matched, in rule order. Markers are file names or globs
+// such as *.csproj.
+func Detect(dir string, rules []structs.DetectRule) ([]Match, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory: %w", err)
+	}
+
+	var matches []Match
+	for _, rule := range rules {
+		var files []string
+		for _, entry := range entries {
+			ok, err := filepath.Match(rule.Marker, entry.Name())
+			if err != nil {
+				return nil, fmt.Errorf("invalid marker '%s': %w", rule.Marker, err)
+			}
+			if ok {
+				files = append(files, entry.Name())
+			}
+		}
+		if len(files) > 0 {
+			matches = append(matches, Match{Rule: rule, Files: files})
+		}
+	}
+	return matches, nil
+}
+
+// Templates returns the template names suggested by the matches, without
+// duplicates and in the order they were first suggested
+func Templates(matches []Match) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		for _, name := range match.Rule.Templates {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,49 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name              string
+		files             []string
+		userRules         []structs.DetectRule
+		expectedTemplates []string
+	}{
+		{"no markers", []string{"README.md"}, nil, nil},
+		{"go module", []string{"go.mod", "main.go"}, nil, []string{"go"}},
+		{"several stacks", []string{"package.json", "go.mod", "main.tf"}, nil, []string{"go", "node", "terraform"}},
+		{"glob marker", []string{"App.csproj"}, nil, []string{"visualstudio"}},
+		{"python markers dedupe", []string{"pyproject.toml", "requirements.txt"}, nil, []string{"python"}},
+		{"user rule", []string{"deno.json"},
+			[]structs.DetectRule{{Marker: "deno.json", Templates: []string{"deno"}}}, []string{"deno"}},
+		{"user rule overrides default", []string{"go.mod"},
+			[]structs.DetectRule{{Marker: "go.mod", Templates: []string{"golang", "goreleaser"}}},
+			[]string{"golang", "goreleaser"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", file, err)
+				}
+			}
+			matches, err := Detect(dir, Rules(tt.userRules))
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			templates := Templates(matches)
+			if !reflect.DeepEqual(templates, tt.expectedTemplates) {
+				t.Errorf("Expected templates %v but got %v", tt.expectedTemplates, templates)
+			}
+		})
+	}
+}
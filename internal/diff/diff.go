@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/ignore"
+)
+
+// Op is the kind of change of a line
+type Op byte
+
+const (
+	Equal  Op = ' '
+	Delete Op = '-'
+	Insert Op = '+'
+)
+
+// Line is a line of an edit script
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the edit script turning a into b, keeping the longest
+// common subsequence of lines and deleting before inserting
+func Lines(a, b []string) []Line {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var script []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			script = append(script, Line{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			script = append(script, Line{Delete, a[i]})
+			i++
+		default:
+			script = append(script, Line{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		script = append(script, Line{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		script = append(script, Line{Insert, b[j]})
+	}
+	return script
+}
+
+// Unified returns the unified diff turning a into b with the given number
+// of context lines around each change, or "" when a and b are equal
+func Unified(aName, bName string, a, b []string, context int) string {
+	script := Lines(a, b)
+
+	var sb strings.Builder
+	// aLine and bLine are the line numbers, counted from 0, of the next
+	// line of a and b in the script
+	aLine, bLine := 0, 0
+	for start := 0; start < len(script); {
+		if script[start].Op == Equal {
+			start++
+			aLine++
+			bLine++
+			continue
+		}
+
+		// widen the hunk to its context and merge the changes whose
+		// context overlaps
+		hunkStart := max(start-context, 0)
+		end := start
+		for end < len(script) {
+			next := end
+			for next < len(script) && script[next].Op != Equal {
+				next++
+			}
+			equalRun := next
+			for equalRun < len(script) && script[equalRun].Op == Equal {
+				equalRun++
+			}
+			end = next
+			if equalRun == len(script) || equalRun-next > 2*context {
+				break
+			}
+			end = equalRun
+		}
+		hunkEnd := min(end+context, len(script))
+
+		aStart, bStart := aLine-(start-hunkStart), bLine-(start-hunkStart)
+		aCount, bCount := 0, 0
+		for _, line := range script[hunkStart:hunkEnd] {
+			if line.Op != Insert {
+				aCount++
+			}
+			if line.Op != Delete {
+				bCount++
+			}
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, line := range script[hunkStart:hunkEnd] {
+			sb.WriteString(string(line.Op) + line.Text + "\n")
+		}
+
+		for _, line := range script[start:hunkEnd] {
+			if line.Op != Insert {
+				aLine++
+			}
+			if line.Op != Delete {
+				bLine++
+			}
+		}
+		start = hunkEnd
+	}
+	return sb.String()
+}
+
+// hunkRange formats the start line and count of a hunk, where a hunk that
+// is empty on one side names the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Patterns compares two sets of patterns, ignoring their order, comments,
+// blank lines and duplicates. It returns the patterns only in a and the
+// patterns only in b, each in the order they first appear.
+func Patterns(a, b []ignore.Pattern) (removed, added []ignore.Pattern) {
+	return missingFrom(a, b), missingFrom(b, a)
+}
+
+// missingFrom returns the patterns of from whose key is not in other
+func missingFrom(from, other []ignore.Pattern) []ignore.Pattern {
+	keys := make(map[string]bool)
+	for _, p := range other {
+		keys[p.Key()] = true
+	}
+	var missing []ignore.Pattern
+	for _, p := range from {
+		if !keys[p.Key()] {
+			keys[p.Key()] = true
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
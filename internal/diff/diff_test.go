@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SQUASHD/gogi/internal/ignore"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"added to empty", "", "a\nb\n", "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"changed line", "a\nb\nc\n", "a\nx\nc\n", "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"separate hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n"},
+		{"merged hunks", "1\n2\n3\n4\n5\n", "x\n2\n3\n4\ny\n",
+			"--- old\n+++ new\n@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n 4\n-5\n+y\n"},
+		{"removed at end", "a\nb\n", "a\n", "--- old\n+++ new\n@@ -1,2 +1 @@\n a\n-b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unified := Unified("old", "new", split(tt.a), split(tt.b), 3)
+			if unified != tt.expected {
+				t.Errorf("Expected\n%s\nbut got\n%s", tt.expected, unified)
+			}
+		})
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	a := ignore.Parse([]byte("# deps\nnode_modules/\n*.log\n\n/dist\n*.log\n"))
+	b := ignore.Parse([]byte("*.log\nnode_modules/\ndist\n.env\n"))
+
+	removed, added := Patterns(a, b)
+	if raws(removed) != "/dist" {
+		t.Errorf("Expected removed /dist but got %s", raws(removed))
+	}
+	if raws(added) != "dist,.env" {
+		t.Errorf("Expected added dist,.env but got %s", raws(added))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		expected  string
+		conflicts int
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"theirs changed", "a\nb\nc\n", "a\nb\nc\n", "a\nx\nc\n", "a\nx\nc\n", 0},
+		{"ours added at end", "a\nb\n", "a\nb\nlocal\n", "a\nb\n", "a\nb\nlocal\n", 0},
+		{"separate changes", "a\nb\nc\nd\n", "a\nb\nc\nd\nlocal\n", "a\nx\nc\nd\n", "a\nx\nc\nd\nlocal\n", 0},
+		{"same change", "a\nb\nc\n", "a\nx\nc\n", "a\nx\nc\n", "a\nx\nc\n", 0},
+		{"deleted by theirs", "a\nb\nc\n", "a\nb\nc\n", "a\nc\n", "a\nc\n", 0},
+		{"conflicting change", "a\nb\nc\n", "a\ny\nc\n", "a\nx\nc\n",
+			"a\n<<<<<<< ours\ny\n||||||| base\nb\n=======\nx\n>>>>>>> theirs\nc\n", 1},
+		{"conflicting insertions", "a\n", "a\ny\n", "a\nx\n",
+			"a\n<<<<<<< ours\ny\n||||||| base\n=======\nx\n>>>>>>> theirs\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := Merge(split(tt.base), split(tt.ours), split(tt.theirs), "ours", "base", "theirs")
+			if got := strings.Join(merged, "\n") + "\n"; got != tt.expected {
+				t.Errorf("Expected\n%s\nbut got\n%s", tt.expected, got)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("Expected %d conflicts but got %d", tt.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func split(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func raws(patterns []ignore.Pattern) string {
+	var r []string
+	for _, p := range patterns {
+		r = append(r, p.Raw)
+	}
+	return strings.Join(r, ",")
+}
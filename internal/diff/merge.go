@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers, in the diff3 style of git
+const (
+	MarkerOurs   = "<<<<<<<"
+	MarkerBase   = "|||||||"
+	MarkerSplit  = "======="
+	MarkerTheirs = ">>>>>>>"
+)
+
+// Merge applies the changes made from base to ours and from base to theirs
+// to base. Where both changed the same lines differently, the merged lines
+// hold a conflict between markers naming the versions. It returns the
+// merged lines and the number of conflicts.
+func Merge(base, ours, theirs []string, oursName, baseName, theirsName string) ([]string, int) {
+	oursAt := matches(base, ours)
+	theirsAt := matches(base, theirs)
+
+	var merged []string
+	conflicts := 0
+	// i, o and t are the next lines of base, ours and theirs
+	i, o, t := 0, 0, 0
+	for i < len(base) || o < len(ours) || t < len(theirs) {
+		if i < len(base) && oursAt[i] == o && theirsAt[i] == t {
+			merged = append(merged, base[i])
+			i, o, t = i+1, o+1, t+1
+			continue
+		}
+
+		// the changed chunk ends at the next line of base both kept
+		end, oEnd, tEnd := i, len(ours), len(theirs)
+		for ; end < len(base); end++ {
+			if oursAt[end] >= 0 && theirsAt[end] >= 0 {
+				oEnd, tEnd = oursAt[end], theirsAt[end]
+				break
+			}
+		}
+		baseChunk, oursChunk, theirsChunk := base[i:end], ours[o:oEnd], theirs[t:tEnd]
+		switch {
+		case slices.Equal(oursChunk, baseChunk), slices.Equal(oursChunk, theirsChunk):
+			merged = append(merged, theirsChunk...)
+		case slices.Equal(theirsChunk, baseChunk):
+			merged = append(merged, oursChunk...)
+		default:
+			conflicts++
+			merged = append(merged, MarkerOurs+" "+oursName)
+			merged = append(merged, oursChunk...)
+			merged = append(merged, MarkerBase+" "+baseName)
+			merged = append(merged, baseChunk...)
+			merged = append(merged, MarkerSplit)
+			merged = append(merged, theirsChunk...)
+			merged = append(merged, MarkerTheirs+" "+theirsName)
+		}
+		i, o, t = end, oEnd, tEnd
+	}
+	return merged, conflicts
+}
+
+// IsConflictMarker reports whether line is one of the markers Merge puts
+// around a conflict
+func IsConflictMarker(line string) bool {
+	if line == MarkerSplit {
+		return true
+	}
+	for _, marker := range []string{MarkerOurs, MarkerBase, MarkerTheirs} {
+		if line == marker || strings.HasPrefix(line, marker+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns the index of the line of other every line of base is
+// kept as, or -1 for the lines other deleted
+func matches(base, other []string) []int {
+	at := make([]int, len(base))
+	i, j := 0, 0
+	for _, line := range Lines(base, other) {
+		switch line.Op {
+		case Equal:
+			at[i] = j
+			i++
+			j++
+		case Delete:
+			at[i] = -1
+			i++
+		case Insert:
+			j++
+		}
+	}
+	return at
+}
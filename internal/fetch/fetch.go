@@ -0,0 +1,159 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the server has no file at the URL
+	ErrNotFound = errors.New("not found")
+	// ErrNotCached is returned in offline mode for URLs never fetched
+	ErrNotCached = errors.New("not cached")
+)
+
+// Client fetches files over HTTP and keeps a copy of every response in
+// its cache directory. Cached copies are revalidated with ETag and
+// Last-Modified, and used as is when the network is unavailable.
+type Client struct {
+	HTTP     *http.Client
+	CacheDir string
+	// Offline makes the client answer from the cache only
+	Offline bool
+}
+
+// Response is the content fetched from a URL
+type Response struct {
+	Content []byte
+	// FromCache is set when the content was served from the cache
+	FromCache bool
+	// Stale is set when the cache was used because the server could not
+	// be reached, so the content may be out of date
+	Stale bool
+}
+
+// cacheEntry is the metadata stored next to a cached response
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// NewClient creates a client caching responses under cacheDir
+func NewClient(cacheDir string) *Client {
+	return &Client{
+		HTTP:     &http.Client{Timeout: 10 * time.Second},
+		CacheDir: cacheDir,
+	}
+}
+
+// Fetch returns the content at url, from the server when it can be
+// reached and from the cache otherwise
+func (c *Client) Fetch(url string) (*Response, error) {
+	entry, cached, cacheErr := c.readCache(url)
+	if c.Offline {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("%s: %w", url, ErrNotCached)
+		}
+		return &Response{Content: cached, FromCache: true}, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %w", url, err)
+	}
+	if cacheErr == nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if cacheErr == nil {
+			return &Response{Content: cached, FromCache: true, Stale: true}, nil
+		}
+		return nil, fmt.Errorf("unable to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cacheErr == nil:
+		return &Response{Content: cached, FromCache: true}, nil
+	case resp.StatusCode >= http.StatusInternalServerError && cacheErr == nil:
+		return &Response{Content: cached, FromCache: true, Stale: true}, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", url, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unable to fetch %s: %s", url, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response from %s: %w", url, err)
+	}
+	err = c.writeCache(url, content, cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Content: content}, nil
+}
+
+// cachePaths returns the paths of the cached content and its metadata
+func (c *Client) cachePaths(url string) (string, string) {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.CacheDir, key), filepath.Join(c.CacheDir, key+".json")
+}
+
+func (c *Client) readCache(url string) (cacheEntry, []byte, error) {
+	var entry cacheEntry
+	contentPath, metaPath := c.cachePaths(url)
+	meta, err := os.ReadFile(metaPath)
+	if err != nil {
+		return entry, nil, err
+	}
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return entry, nil, err
+	}
+	content, err := os.ReadFile(contentPath)
+	if err != nil {
+		return entry, nil, err
+	}
+	return entry, content, nil
+}
+
+func (c *Client) writeCache(url string, content []byte, entry cacheEntry) error {
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return fmt.Errorf("unable to create cache directory: %w", err)
+	}
+	contentPath, metaPath := c.cachePaths(url)
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(contentPath, content, 0644); err != nil {
+		return fmt.Errorf("unable to write cache: %w", err)
+	}
+	if err := os.WriteFile(metaPath, meta, 0644); err != nil {
+		return fmt.Errorf("unable to write cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestServer(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/go.gitignore", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("*.exe\n"))
+	})
+	mux.HandleFunc("/dated.gitignore", func(w http.ResponseWriter, r *http.Request) {
+		const modified = "Mon, 02 Jan 2006 15:04:05 GMT"
+		if r.Header.Get("If-Modified-Since") == modified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", modified)
+		w.Write([]byte("*.tmp\n"))
+	})
+	mux.HandleFunc("/broken.gitignore", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFetch(t *testing.T) {
+	var hits int32
+	server := newTestServer(t, &hits)
+	client := NewClient(t.TempDir())
+
+	resp, err := client.Fetch(server.URL + "/go.gitignore")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(resp.Content) != "*.exe\n" || resp.FromCache {
+		t.Errorf("Expected fresh content but got %q, from cache %v", resp.Content, resp.FromCache)
+	}
+
+	resp, err = client.Fetch(server.URL + "/go.gitignore")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(resp.Content) != "*.exe\n" || !resp.FromCache || resp.Stale {
+		t.Errorf("Expected content revalidated by ETag but got %q, from cache %v", resp.Content, resp.FromCache)
+	}
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected the server to be asked twice but it was asked %d times", hits)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err = client.Fetch(server.URL + "/dated.gitignore")
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if string(resp.Content) != "*.tmp\n" || !resp.FromCache {
+		t.Errorf("Expected content revalidated by Last-Modified but got %q, from cache %v", resp.Content, resp.FromCache)
+	}
+
+	if _, err := client.Fetch(server.URL + "/missing.gitignore"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got %v", err)
+	}
+	if _, err := client.Fetch(server.URL + "/broken.gitignore"); err == nil {
+		t.Errorf("Expected an error for a server error without cache")
+	}
+
+	client.Offline = true
+	resp, err = client.Fetch(server.URL + "/go.gitignore")
+	if err != nil || !resp.FromCache {
+		t.Errorf("Expected cached content when offline but got %v", err)
+	}
+	if _, err := client.Fetch(server.URL + "/other.gitignore"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("Expected ErrNotCached but got %v", err)
+	}
+	client.Offline = false
+
+	server.Close()
+	resp, err = client.Fetch(server.URL + "/go.gitignore")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(resp.Content) != "*.exe\n" || !resp.Stale {
+		t.Errorf("Expected stale cached content when the server is down but got %q, stale %v", resp.Content, resp.Stale)
+	}
+	if _, err := client.Fetch(server.URL + "/missing.gitignore"); err == nil {
+		t.Errorf("Expected an error when the server is down and nothing is cached")
+	}
+}
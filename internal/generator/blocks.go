@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	blockStartPrefix = "# >>> gogi:"
+	blockEndPrefix   = "# <<< gogi:"
+)
+
+// Block is a template written by gogi into a .gitignore file, wrapped in
+// start and end marker comments carrying the template name
+type Block struct {
+	Name string
+	// Start and End are the line indexes of the start and end markers
+	Start int
+	End   int
+	// Lines holds the template lines between the markers
+	Lines []string
+}
+
+// BlockStart returns the marker line opening the block for the named template
+func BlockStart(name string) string {
+	return blockStartPrefix + name
+}
+
+// BlockEnd returns the marker line closing the block for the named template
+func BlockEnd(name string) string {
+	return blockEndPrefix + name
+}
+
+// RenderBlock wraps the template content in its block markers
+func RenderBlock(name string, content []byte) []byte {
+	var sb strings.Builder
+	sb.WriteString(BlockStart(name) + "\n")
+	sb.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(BlockEnd(name) + "\n")
+	return []byte(sb.String())
+}
+
+// ParseBlocks finds the gogi managed blocks among the lines of a
+// .gitignore file. Blocks can not be nested and every start marker must
+// be closed by the end marker of the same template.
+func ParseBlocks(lines []string) ([]Block, error) {
+	var blocks []Block
+	var current *Block
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(trimmed, blockStartPrefix):
+			name := strings.TrimPrefix(trimmed, blockStartPrefix)
+			if current != nil {
+				return nil, fmt.Errorf("line %d: block '%s' starts before block '%s' is closed", i+1, name, current.Name)
+			}
+			current = &Block{Name: name, Start: i}
+		case strings.HasPrefix(trimmed, blockEndPrefix):
+			name := strings.TrimPrefix(trimmed, blockEndPrefix)
+			if current == nil || current.Name != name {
+				return nil, fmt.Errorf("line %d: unexpected end of block '%s'", i+1, name)
+			}
+			current.End = i
+			current.Lines = lines[current.Start+1 : i]
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("line %d: block '%s' is never closed", current.Start+1, current.Name)
+	}
+	return blocks, nil
+}
+
+// FindBlock returns the block of the named template, if any
+func FindBlock(blocks []Block, name string) (Block, bool) {
+	for _, block := range blocks {
+		if block.Name == name {
+			return block, true
+		}
+	}
+	return Block{}, false
+}
+
+// UpsertBlock replaces the block of the named template in content with
+// the given template content, or appends a new block when the template
+// has not been written before. Lines outside the block are left alone.
+func UpsertBlock(content []byte, name string, templContent []byte) ([]byte, error) {
+	lines := SplitLines(content)
+	blocks, err := ParseBlocks(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := SplitLines(RenderBlock(name, templContent))
+	if block, ok := FindBlock(blocks, name); ok {
+		updated := make([]string, 0, len(lines)-len(block.Lines)+len(rendered))
+		updated = append(updated, lines[:block.Start]...)
+		updated = append(updated, rendered...)
+		updated = append(updated, lines[block.End+1:]...)
+		return JoinLines(updated), nil
+	}
+
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+	return JoinLines(append(lines, rendered...)), nil
+}
+
+// SplitLines splits content into lines without their line endings
+func SplitLines(content []byte) []string {
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// JoinLines joins lines back into file content ending in a newline
+func JoinLines(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// RemoveBlock takes the lines contributed by the named template out of
+// content and returns the updated content along with the removed lines.
+// The template's block is removed when there is one, otherwise the
+// template content is looked for as it was appended before gogi wrote
+// blocks. Every other line is kept.
+func RemoveBlock(content []byte, name string, templContent []byte) ([]byte, []string, error) {
+	lines := SplitLines(content)
+	blocks, err := ParseBlocks(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start, end := -1, -1
+	if block, ok := FindBlock(blocks, name); ok {
+		start, end = block.Start, block.End
+	} else if templLines := SplitLines(templContent); len(templLines) > 0 {
+		start = findRun(lines, templLines, blocks)
+		end = start + len(templLines) - 1
+	}
+	if start < 0 {
+		return nil, nil, fmt.Errorf("template '%s' was not found in .gitignore", name)
+	}
+
+	removed := lines[start : end+1]
+	updated := make([]string, 0, len(lines)-len(removed))
+	updated = append(updated, lines[:start]...)
+	rest := lines[end+1:]
+	// drop the blank line that separated the block from what came before
+	if start > 0 && strings.TrimSpace(lines[start-1]) == "" &&
+		(len(rest) == 0 || strings.TrimSpace(rest[0]) == "") {
+		updated = updated[:len(updated)-1]
+	}
+	updated = append(updated, rest...)
+	return JoinLines(updated), removed, nil
+}
+
+// findRun returns the index of the first run of lines equal to run that
+// lies outside of every block, or -1 when there is none
+func findRun(lines, run []string, blocks []Block) int {
+	for i := 0; i+len(run) <= len(lines); i++ {
+		if insideBlock(blocks, i, i+len(run)-1) {
+			continue
+		}
+		matched := true
+		for j := range run {
+			if lines[i+j] != run[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
+// insideBlock reports whether any line between start and end belongs to a block
+func insideBlock(blocks []Block, start, end int) bool {
+	for _, block := range blocks {
+		if start <= block.End && end >= block.Start {
+			return true
+		}
+	}
+	return false
+}
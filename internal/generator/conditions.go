@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// DirectivePrefix starts the directive comments that control which
+// lines of a template are rendered
+const DirectivePrefix = "#gogi:"
+
+// Target is the platform and project a template is rendered for
+type Target struct {
+	// OS and Arch default to the platform gogi runs on
+	OS   string
+	Arch string
+	// Stack holds the template names detected for the project
+	Stack []string
+	// NoEnv keeps the environment out of rendering, for output that has
+	// to be the same on every machine: env= tests are rejected and the
+	// GOGI_ variables are not read
+	NoEnv bool
+}
+
+// osAliases maps common names of operating systems to their GOOS value
+var osAliases = map[string]string{
+	"macos": "darwin",
+	"mac":   "darwin",
+	"osx":   "darwin",
+	"win":   "windows",
+}
+
+// expansionDirectives are resolved after the conditions by the expansion
+// pass of RenderTemplate, see expansion.render
+var expansionDirectives = map[string]bool{
+	"include": true,
+	"extends": true,
+	"drop":    true,
+}
+
+// conditional is an open #gogi:if directive
+type conditional struct {
+	line int
+	// parentActive is whether the lines around the directive are kept
+	parentActive bool
+	holds        bool
+	inElse       bool
+}
+
+// ApplyConditions evaluates the #gogi:if, #gogi:else and #gogi:endif
+// directives of a template, keeping the lines whose conditions hold for
+// target. Directive lines are always removed, and unknown or unbalanced
+// directives are reported with their line number.
+//
+// A condition is one or more space separated tests that must all hold,
+// each of them key=value or key!=value where value may list alternatives
+// separated by commas: os=darwin,windows, arch!=arm64, stack=node, or
+// env=CI which holds when the CI variable is set and env=CI=true which
+// compares its value.
+//
+// The #gogi:include, #gogi:extends and #gogi:drop directives of the kept
+// lines are left for RenderTemplate to resolve.
+func ApplyConditions(name string, content []byte, target Target) ([]byte, error) {
+	if !strings.Contains(string(content), DirectivePrefix) {
+		return content, nil
+	}
+	lines, _, err := applyConditions(name, content, target)
+	if err != nil {
+		return nil, err
+	}
+	return JoinLines(lines), nil
+}
+
+// applyConditions returns the lines of content kept by its conditions
+// along with their line numbers in content
+func applyConditions(name string, content []byte, target Target) ([]string, []int, error) {
+	target = target.withDefaults()
+
+	var kept []string
+	var keptLineNos []int
+	var stack []conditional
+	active := true
+	for i, line := range SplitLines(content) {
+		lineNo := i + 1
+		directive, expr, ok := parseDirective(line)
+		if !ok || expansionDirectives[directive] {
+			if active {
+				kept = append(kept, line)
+				keptLineNos = append(keptLineNos, lineNo)
+			}
+			continue
+		}
+
+		switch directive {
+		case "if":
+			holds, err := target.evaluate(expr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("template '%s' line %d: %w", name, lineNo, err)
+			}
+			stack = append(stack, conditional{line: lineNo, parentActive: active, holds: holds})
+			active = active && holds
+		case "else":
+			if len(stack) == 0 {
+				return nil, nil, fmt.Errorf("template '%s' line %d: #gogi:else without #gogi:if", name, lineNo)
+			}
+			top := &stack[len(stack)-1]
+			if top.inElse {
+				return nil, nil, fmt.Errorf("template '%s' line %d: second #gogi:else for the #gogi:if on line %d", name, lineNo, top.line)
+			}
+			top.inElse = true
+			active = top.parentActive && !top.holds
+		case "endif":
+			if len(stack) == 0 {
+				return nil, nil, fmt.Errorf("template '%s' line %d: #gogi:endif without #gogi:if", name, lineNo)
+			}
+			active = stack[len(stack)-1].parentActive
+			stack = stack[:len(stack)-1]
+		default:
+			return nil, nil, fmt.Errorf("template '%s' line %d: unknown directive '%s'", name, lineNo, DirectivePrefix+directive)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, nil, fmt.Errorf("template '%s' line %d: #gogi:if is never closed with #gogi:endif", name, stack[len(stack)-1].line)
+	}
+	return kept, keptLineNos, nil
+}
+
+// parseDirective splits a #gogi: directive line into the directive and
+// its arguments, and reports whether the line is a directive
+func parseDirective(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, DirectivePrefix) {
+		return "", "", false
+	}
+	directive, args, _ := strings.Cut(strings.TrimPrefix(trimmed, DirectivePrefix), " ")
+	return directive, strings.TrimSpace(args), true
+}
+
+// withDefaults fills in the platform gogi runs on
+func (t Target) withDefaults() Target {
+	if t.OS == "" {
+		t.OS = runtime.GOOS
+	}
+	if t.Arch == "" {
+		t.Arch = runtime.GOARCH
+	}
+	t.OS = normalizeOS(t.OS)
+	return t
+}
+
+// evaluate reports whether every test of the condition holds
+func (t Target) evaluate(expr string) (bool, error) {
+	tests := strings.Fields(expr)
+	if len(tests) == 0 {
+		return false, fmt.Errorf("#gogi:if without a condition")
+	}
+	for _, test := range tests {
+		holds, err := t.evaluateTest(test)
+		if err != nil {
+			return false, err
+		}
+		if !holds {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateTest evaluates a single key=value or key!=value test
+func (t Target) evaluateTest(test string) (bool, error) {
+	key, value, ok := strings.Cut(test, "=")
+	negate := strings.HasSuffix(key, "!")
+	key = strings.TrimSuffix(key, "!")
+	if !ok || key == "" || value == "" {
+		return false, fmt.Errorf("invalid condition '%s'. use key=value or key!=value", test)
+	}
+
+	var holds bool
+	for _, alternative := range strings.Split(value, ",") {
+		switch key {
+		case "os":
+			holds = normalizeOS(alternative) == t.OS
+		case "arch":
+			holds = strings.EqualFold(alternative, t.Arch)
+		case "stack":
+			for _, name := range t.Stack {
+				if strings.EqualFold(name, alternative) {
+					holds = true
+				}
+			}
+		case "env":
+			if t.NoEnv {
+				return false, fmt.Errorf("condition '%s' depends on the environment, which is not allowed here", test)
+			}
+			envName, envValue, compare := strings.Cut(alternative, "=")
+			actual, set := os.LookupEnv(envName)
+			holds = set && actual != "" && (!compare || actual == envValue)
+		default:
+			return false, fmt.Errorf("unknown condition key '%s'. use os, arch, env or stack", key)
+		}
+		if holds {
+			break
+		}
+	}
+	return holds != negate, nil
+}
+
+// normalizeOS maps an operating system name to its GOOS value
+func normalizeOS(name string) string {
+	name = strings.ToLower(name)
+	if goos, ok := osAliases[name]; ok {
+		return goos
+	}
+	return name
+}
@@ -1,75 +1,250 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/ignore"
+	"github.com/SQUASHD/gogi/internal/structs"
 )
 
-// GenerateGitignore creates or overwrites a .gitignore file at gitignorePath
-// using the template found at templPath.
-func GenerateGitignore(templPath, cwd string) error {
-	sourceFile, err := os.Open(templPath)
+// GenerateGitignore creates or overwrites a .gitignore file in cwd
+// using the given templates in order, each wrapped in its own block.
+// With opts.Header the file starts with a provenance header.
+func GenerateGitignore(cwd string, opts Options, templates ...structs.Template) (Result, error) {
+	content, result, err := ComposeTemplates(templates, opts)
 	if err != nil {
-		return fmt.Errorf("unable to open template file: %w", err)
+		return result, err
+	}
+
+	if opts.Header {
+		names := make([]string, 0, len(templates))
+		for _, templ := range templates {
+			names = append(names, templ.Name)
+		}
+		content = WithProvenance(NewProvenance(names, opts.Merge), content)
+	}
+	return result, WriteGitignore(cwd, content, opts.Record)
+}
+
+// AppendTemplate adds the given templates to the .gitignore file in cwd.
+// A template that was written before has its block replaced in place
+// rather than being added a second time. A provenance header is updated
+// to list the appended templates.
+func AppendTemplate(cwd string, opts Options, templates ...structs.Template) (Result, error) {
+	var result Result
+	if len(templates) == 0 {
+		return result, fmt.Errorf("no templates to append")
 	}
-	defer sourceFile.Close()
 
-	gitignorePath := filepath.Join(cwd, ".gitignore")
-	destinationFile, err := os.Create(gitignorePath)
+	giPath := filepath.Join(cwd, ".gitignore")
+	content, err := os.ReadFile(giPath)
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	sections := make([]Section, 0, len(templates))
+	for _, templ := range templates {
+		templContent, err := ReadTemplate(templ, opts)
+		if err != nil {
+			return result, err
+		}
+		sections = append(sections, Section{Name: templ.Name, Content: templContent})
+	}
+	content, result, err = UpsertSections(content, sections, opts)
 	if err != nil {
-		return fmt.Errorf("unable to create .gitignore file: %w", err)
+		return result, err
 	}
-	defer destinationFile.Close()
 
-	_, err = io.Copy(destinationFile, sourceFile)
+	content, err = RefreshProvenance(content, func(names []string) []string {
+		for _, templ := range templates {
+			if !containsFold(names, templ.Name) {
+				names = append(names, templ.Name)
+			}
+		}
+		return names
+	})
 	if err != nil {
-		return fmt.Errorf("unable to write to .gitignore file: %w", err)
+		return result, fmt.Errorf("unable to update .gitignore file: %w", err)
 	}
 
-	return nil
+	return result, WriteGenerated(giPath, content, opts.Record)
 }
 
-// AppendTemplate appends the contents of the template found at templPath
-func AppendTemplate(cwd, templPath string) error {
-	template, err := os.Open(templPath)
-	if err != nil {
-		return err
+// UpsertSections writes every section into content as a block, replacing
+// the block of a section that was written before in place and appending
+// the others. In merge mode the patterns content already has around a
+// block are left out of it.
+func UpsertSections(content []byte, sections []Section, opts Options) ([]byte, Result, error) {
+	var result Result
+	for _, section := range sections {
+		sectionContent := section.Content
+		if opts.Merge {
+			before, after, err := surroundingPatterns(content, section.Name)
+			if err != nil {
+				return nil, result, fmt.Errorf("unable to update .gitignore file: %w", err)
+			}
+			var skipped int
+			sectionContent, skipped = dedupe(sectionContent, before, after)
+			result.Skipped += skipped
+		}
+		var err error
+		content, err = UpsertBlock(content, section.Name, sectionContent)
+		if err != nil {
+			return nil, result, fmt.Errorf("unable to update .gitignore file: %w", err)
+		}
 	}
-	defer template.Close()
+	return content, result, nil
+}
 
-	giPath := filepath.Join(cwd, ".gitignore")
-	file, err := os.OpenFile(giPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// SyncSections makes the blocks of content match the sections: blocks of
+// other templates are removed and every section is upserted. Lines outside
+// the blocks are kept.
+func SyncSections(content []byte, sections []Section, opts Options) ([]byte, Result, error) {
+	blocks, err := ParseBlocks(SplitLines(content))
 	if err != nil {
-		return err
+		return nil, Result{}, fmt.Errorf("unable to update .gitignore file: %w", err)
+	}
+	for _, block := range blocks {
+		wanted := false
+		for _, section := range sections {
+			if section.Name == block.Name {
+				wanted = true
+				break
+			}
+		}
+		if wanted {
+			continue
+		}
+		content, _, err = RemoveBlock(content, block.Name, nil)
+		if err != nil {
+			return nil, Result{}, fmt.Errorf("unable to update .gitignore file: %w", err)
+		}
+	}
+	return UpsertSections(content, sections, opts)
+}
+
+// containsFold reports whether names holds name, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
 	}
-	defer file.Close()
+	return false
+}
 
-	_, err = io.Copy(file, template)
+// ReadGitignore returns the content of the .gitignore file in cwd
+func ReadGitignore(cwd string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(cwd, ".gitignore"))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to read .gitignore file: %w", err)
 	}
+	return content, nil
+}
 
-	return nil
+// WriteGitignore replaces the content of the .gitignore file in cwd, see
+// WriteGenerated
+func WriteGitignore(cwd string, content []byte, record bool) error {
+	return WriteGenerated(filepath.Join(cwd, ".gitignore"), content, record)
 }
 
-// CreateEmptyTemplateFile creates an empty template file at templPath
-func CreateEmptyTemplateFile(projectDir, templName string) error {
-	filename := templName + ".gitignore"
-	templPath := filepath.Join(projectDir, filename)
+// recordSuffix is added to the path of a generated file to name the
+// record of the content gogi last generated for it
+const recordSuffix = ".gogi-last"
 
-	file, err := os.Create(templPath)
-	if err != nil {
-		return err
+// RecordPath returns the path of the record of the content last generated
+// for the file at path
+func RecordPath(path string) string {
+	return path + recordSuffix
+}
+
+// WriteGenerated replaces the content of the file at path with generated
+// content. It records the content as the one last generated for the file
+// when record is set or the file already has a record.
+func WriteGenerated(path string, content []byte, record bool) error {
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("unable to write to %s file: %w", filepath.Base(path), err)
 	}
-	if err = file.Close(); err != nil {
-		return err
+	if !record {
+		if _, err := os.Stat(RecordPath(path)); err != nil {
+			return nil
+		}
 	}
+	return WriteRecord(path, content)
+}
 
+// WriteRecord records content as the content last generated for the file
+// at path
+func WriteRecord(path string, content []byte) error {
+	if err := os.WriteFile(RecordPath(path), content, 0644); err != nil {
+		return fmt.Errorf("unable to record the generated %s file: %w", filepath.Base(path), err)
+	}
 	return nil
 }
 
+// ReadRecord returns the content last generated for the file at path. It
+// returns an error satisfying errors.Is(err, os.ErrNotExist) when there is
+// no record.
+func ReadRecord(path string) ([]byte, error) {
+	return os.ReadFile(RecordPath(path))
+}
+
+// Section is the content of a named template to compose
+type Section struct {
+	Name    string
+	Content []byte
+}
+
+// ComposeTemplates reads and renders the given templates and joins their
+// contents in order, wrapping each of them in a block headed by the
+// template name
+func ComposeTemplates(templates []structs.Template, opts Options) ([]byte, Result, error) {
+	sections := make([]Section, 0, len(templates))
+	for _, templ := range templates {
+		content, err := ReadTemplate(templ, opts)
+		if err != nil {
+			return nil, Result{}, err
+		}
+		sections = append(sections, Section{Name: templ.Name, Content: content})
+	}
+	return Compose(sections, opts)
+}
+
+// Compose joins the sections in order, wrapping each of them in a block
+// headed by the template name
+func Compose(sections []Section, opts Options) ([]byte, Result, error) {
+	var result Result
+	if len(sections) == 0 {
+		return nil, result, fmt.Errorf("no templates to compose")
+	}
+
+	var buf bytes.Buffer
+	for i, section := range sections {
+		content := section.Content
+		if opts.Merge {
+			var skipped int
+			content, skipped = dedupe(content, ignore.Parse(buf.Bytes()), nil)
+			result.Skipped += skipped
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.Write(RenderBlock(section.Name, content))
+	}
+
+	return buf.Bytes(), result, nil
+}
+
+// WriteTemplateFile writes content to the template file at templPath,
+// creating an empty template when there is no content
+func WriteTemplateFile(templPath string, content []byte) error {
+	return os.WriteFile(templPath, content, 0644)
+}
+
 // CreateTemplatePath creates a path to a template file
 func CreateTemplatePath(projectDir, templName string) string {
 	return filepath.Join(projectDir, templName+".gitignore")
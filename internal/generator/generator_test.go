@@ -1,9 +1,474 @@
 package generator
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/SQUASHD/gogi/internal/structs"
 )
 
+func writeTestTemplate(t *testing.T, dir, name, content string) structs.Template {
+	t.Helper()
+	path := filepath.Join(dir, name+".gitignore")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template %s: %v", name, err)
+	}
+	return structs.Template{Name: name, Path: path}
+}
+
 func TestGenerateGitignore(t *testing.T) {
-	
+	dir := t.TempDir()
+	goTempl := writeTestTemplate(t, dir, "go", "*.exe\nvendor/\n")
+	macTempl := writeTestTemplate(t, dir, "macos", ".DS_Store")
+
+	tests := []struct {
+		name      string
+		templates []structs.Template
+		wantErr   bool
+		expected  string
+	}{
+		{"single template", []structs.Template{goTempl}, false,
+			"# >>> gogi:go\n*.exe\nvendor/\n# <<< gogi:go\n"},
+		{"multiple templates", []structs.Template{goTempl, macTempl}, false,
+			"# >>> gogi:go\n*.exe\nvendor/\n# <<< gogi:go\n\n# >>> gogi:macos\n.DS_Store\n# <<< gogi:macos\n"},
+		{"order is kept", []structs.Template{macTempl, goTempl}, false,
+			"# >>> gogi:macos\n.DS_Store\n# <<< gogi:macos\n\n# >>> gogi:go\n*.exe\nvendor/\n# <<< gogi:go\n"},
+		{"missing template", []structs.Template{{Name: "missing", Path: filepath.Join(dir, "missing")}}, true, ""},
+		{"no templates", nil, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cwd := t.TempDir()
+			_, err := GenerateGitignore(cwd, Options{}, tt.templates...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateGitignore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(filepath.Join(cwd, ".gitignore"))
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected .gitignore to be %q but got %q", tt.expected, string(got))
+			}
+		})
+	}
+}
+
+func TestAppendTemplate(t *testing.T) {
+	dir := t.TempDir()
+	nodeTempl := writeTestTemplate(t, dir, "node", "node_modules/\n")
+	goTempl := writeTestTemplate(t, dir, "go", "*.exe\n")
+
+	tests := []struct {
+		name      string
+		existing  string
+		templates []structs.Template
+		wantErr   bool
+		expected  string
+	}{
+		{"append to hand written file", "secrets.txt\n", []structs.Template{nodeTempl}, false,
+			"secrets.txt\n\n# >>> gogi:node\nnode_modules/\n# <<< gogi:node\n"},
+		{"append to empty file", "", []structs.Template{nodeTempl}, false,
+			"# >>> gogi:node\nnode_modules/\n# <<< gogi:node\n"},
+		{"re-append replaces block in place",
+			"top\n# >>> gogi:node\nold/\n# <<< gogi:node\nbottom\n", []structs.Template{nodeTempl}, false,
+			"top\n# >>> gogi:node\nnode_modules/\n# <<< gogi:node\nbottom\n"},
+		{"append twice in one call", "", []structs.Template{nodeTempl, nodeTempl}, false,
+			"# >>> gogi:node\nnode_modules/\n# <<< gogi:node\n"},
+		{"append next to existing block",
+			"# >>> gogi:node\nnode_modules/\n# <<< gogi:node\n", []structs.Template{goTempl}, false,
+			"# >>> gogi:node\nnode_modules/\n# <<< gogi:node\n\n# >>> gogi:go\n*.exe\n# <<< gogi:go\n"},
+		{"unclosed block", "# >>> gogi:node\nnode_modules/\n", []structs.Template{nodeTempl}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cwd := t.TempDir()
+			giPath := filepath.Join(cwd, ".gitignore")
+			if err := os.WriteFile(giPath, []byte(tt.existing), 0644); err != nil {
+				t.Fatalf("Failed to write .gitignore: %v", err)
+			}
+
+			_, err := AppendTemplate(cwd, Options{}, tt.templates...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AppendTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(giPath)
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected .gitignore to be %q but got %q", tt.expected, string(got))
+			}
+		})
+	}
+}
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  bool
+		expected []string
+	}{
+		{"no blocks", "a\nb\n", false, nil},
+		{"two blocks", "# >>> gogi:a\nx\n# <<< gogi:a\ny\n# >>> gogi:b\n# <<< gogi:b\n", false, []string{"a", "b"}},
+		{"nested blocks", "# >>> gogi:a\n# >>> gogi:b\n# <<< gogi:b\n# <<< gogi:a\n", true, nil},
+		{"mismatched end", "# >>> gogi:a\n# <<< gogi:b\n", true, nil},
+		{"stray end", "# <<< gogi:a\n", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := ParseBlocks(SplitLines([]byte(tt.content)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBlocks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(blocks) != len(tt.expected) {
+				t.Fatalf("Expected %d blocks but got %d", len(tt.expected), len(blocks))
+			}
+			for i, block := range blocks {
+				if block.Name != tt.expected[i] {
+					t.Errorf("Expected block %d to be %s but got %s", i, tt.expected[i], block.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveBlock(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		templ        string
+		wantErr      bool
+		expected     string
+		expectedGone int
+	}{
+		{"remove block at end", "mine\n\n# >>> gogi:python\n*.pyc\n# <<< gogi:python\n", "*.pyc\n", false,
+			"mine\n", 3},
+		{"remove block in the middle", "a\n# >>> gogi:python\n*.pyc\n# <<< gogi:python\nb\n", "*.pyc\n", false,
+			"a\nb\n", 3},
+		{"remove block with edited template", "# >>> gogi:python\nold\n# <<< gogi:python\nmine\n", "*.pyc\n", false,
+			"mine\n", 3},
+		{"remove content appended without markers", "mine\n*.pyc\n__pycache__/\nmore\n", "*.pyc\n__pycache__/\n", false,
+			"mine\nmore\n", 2},
+		{"template not present", "mine\n", "*.pyc\n", true, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed, err := RemoveBlock([]byte(tt.content), "python", []byte(tt.templ))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveBlock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected content to be %q but got %q", tt.expected, string(got))
+			}
+			if len(removed) != tt.expectedGone {
+				t.Errorf("Expected %d removed lines but got %d", tt.expectedGone, len(removed))
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	logTempl := writeTestTemplate(t, dir, "logs", "# logs\n*.log\n/build/\n.env\n")
+	nodeTempl := writeTestTemplate(t, dir, "node", "*.log\nbuild/\nnode_modules/\n")
+	negTempl := writeTestTemplate(t, dir, "neg", "!keep.log\n")
+
+	tests := []struct {
+		name            string
+		existing        string
+		templates       []structs.Template
+		expected        string
+		expectedSkipped int
+	}{
+		{"skip exact duplicates", "*.log\n.env\n", []structs.Template{logTempl},
+			"*.log\n.env\n\n# >>> gogi:logs\n# logs\n/build/\n# <<< gogi:logs\n", 2},
+		{"skip equivalent duplicates", "/a/b\n**/c\n", []structs.Template{writeTestTemplate(t, dir, "eq", "a/b\nc\n")},
+			"/a/b\n**/c\n\n# >>> gogi:eq\n# <<< gogi:eq\n", 2},
+		{"keep differently anchored", "", []structs.Template{logTempl, nodeTempl},
+			"# >>> gogi:logs\n# logs\n*.log\n/build/\n.env\n# <<< gogi:logs\n\n# >>> gogi:node\nbuild/\nnode_modules/\n# <<< gogi:node\n", 1},
+		{"keep duplicates after a negation", "*.log\n", []structs.Template{negTempl, logTempl},
+			"*.log\n\n# >>> gogi:neg\n!keep.log\n# <<< gogi:neg\n\n# >>> gogi:logs\n# logs\n*.log\n/build/\n.env\n# <<< gogi:logs\n", 0},
+		{"skip duplicates of lines after the block", "# >>> gogi:node\n# <<< gogi:node\nnode_modules/\n", []structs.Template{nodeTempl},
+			"# >>> gogi:node\n*.log\nbuild/\n# <<< gogi:node\nnode_modules/\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cwd := t.TempDir()
+			giPath := filepath.Join(cwd, ".gitignore")
+			if err := os.WriteFile(giPath, []byte(tt.existing), 0644); err != nil {
+				t.Fatalf("Failed to write .gitignore: %v", err)
+			}
+
+			result, err := AppendTemplate(cwd, Options{Merge: true}, tt.templates...)
+			if err != nil {
+				t.Fatalf("AppendTemplate() error = %v", err)
+			}
+			got, err := os.ReadFile(giPath)
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected .gitignore to be %q but got %q", tt.expected, string(got))
+			}
+			if result.Skipped != tt.expectedSkipped {
+				t.Errorf("Expected %d skipped lines but got %d", tt.expectedSkipped, result.Skipped)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("GOGI_OUTDIR", "from-env")
+
+	tests := []struct {
+		name            string
+		content         string
+		defaults        map[string]string
+		vars            map[string]string
+		prompt          func(template, name string) (string, error)
+		expectedContent string
+		expectedErr     string
+	}{
+		{"no placeholders", "*.log\n", nil, nil, nil, "*.log\n", ""},
+		{"set on command line", "{{ .BuildDir }}/\n", map[string]string{"BuildDir": "dist"},
+			map[string]string{"BuildDir": "out"}, nil, "out/\n", ""},
+		{"environment over default", "{{ .OutDir }}/\n", map[string]string{"OutDir": "dist"}, nil, nil, "from-env/\n", ""},
+		{"template default", "{{ .BuildDir }}/\n", map[string]string{"BuildDir": "dist"}, nil, nil, "dist/\n", ""},
+		{"prompted", "*.log\n{{ .ProjectName }}.db\n", nil, nil,
+			func(template, name string) (string, error) { return "app", nil }, "*.log\napp.db\n", ""},
+		{"missing value", "*.log\n\n{{ .ProjectName }}.db\n", nil, nil, nil, "",
+			"template 'test' line 3: no value for ProjectName. try --set ProjectName=value"},
+		{"parse error", "*.log\n{{ .BuildDir }\n", nil, nil, nil, "", "template: test:2: unexpected \"}\" in operand"},
+		{"conditional", "{{ if .Vendor }}vendor/\n{{ end }}*.log\n", nil, map[string]string{"Vendor": ""}, nil, "*.log\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templ := structs.Template{Name: "test", Vars: tt.defaults}
+			content, err := RenderTemplate(templ, []byte(tt.content), Options{Vars: tt.vars, Prompt: tt.prompt})
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q but got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("Expected content %q but got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestApplyConditions(t *testing.T) {
+	t.Setenv("GOGI_TEST_CI", "true")
+	target := Target{OS: "linux", Arch: "amd64", Stack: []string{"go"}}
+
+	tests := []struct {
+		name            string
+		content         string
+		expectedContent string
+		expectedErr     string
+	}{
+		{"no directives", "*.log\n", "*.log\n", ""},
+		{"os holds", "#gogi:if os=linux\n*.so\n#gogi:endif\n", "*.so\n", ""},
+		{"os does not hold", "*.log\n#gogi:if os=darwin\n.DS_Store\n#gogi:endif\n", "*.log\n", ""},
+		{"os alias and alternatives", "#gogi:if os=macos,linux\nshared\n#gogi:endif\n", "shared\n", ""},
+		{"negation", "#gogi:if os!=windows\nunix\n#gogi:endif\n", "unix\n", ""},
+		{"else branch", "#gogi:if os=windows\nThumbs.db\n#gogi:else\n.directory\n#gogi:endif\n", ".directory\n", ""},
+		{"all tests must hold", "#gogi:if os=linux arch=arm64\narm\n#gogi:endif\n", "", ""},
+		{"stack", "#gogi:if stack=go\nvendor/\n#gogi:endif\n", "vendor/\n", ""},
+		{"env set", "#gogi:if env=GOGI_TEST_CI\nci/\n#gogi:endif\n", "ci/\n", ""},
+		{"env value", "#gogi:if env=GOGI_TEST_CI=false\nci/\n#gogi:endif\n", "", ""},
+		{"nested inside false", "#gogi:if os=darwin\n#gogi:if arch=amd64\nx\n#gogi:else\ny\n#gogi:endif\n#gogi:endif\nz\n", "z\n", ""},
+		{"unclosed", "*.log\n#gogi:if os=linux\n*.so\n", "", "template 'test' line 2: #gogi:if is never closed"},
+		{"stray endif", "*.log\n#gogi:endif\n", "", "template 'test' line 2: #gogi:endif without #gogi:if"},
+		{"unknown directive", "#gogi:import go\n", "", "template 'test' line 1: unknown directive '#gogi:import'"},
+		{"unknown key", "#gogi:if distro=arch\n#gogi:endif\n", "", "template 'test' line 1: unknown condition key 'distro'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := ApplyConditions("test", []byte(tt.content), target)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q but got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyConditions() error = %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("Expected content %q but got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestApplyConditionsWithoutEnv(t *testing.T) {
+	t.Setenv("GOGI_TEST_CI", "true")
+	target := Target{OS: "linux", Arch: "amd64", NoEnv: true}
+
+	if _, err := ApplyConditions("test", []byte("#gogi:if env=GOGI_TEST_CI\nci/\n#gogi:endif\n"), target); err == nil ||
+		!strings.Contains(err.Error(), "depends on the environment") {
+		t.Errorf("Expected an env= condition to be rejected but got %v", err)
+	}
+	content, err := ApplyConditions("test", []byte("#gogi:if os=linux\n*.so\n#gogi:endif\n"), target)
+	if err != nil || string(content) != "*.so\n" {
+		t.Errorf("Expected other conditions to be evaluated but got %q, %v", string(content), err)
+	}
+
+	t.Setenv("GOGI_OUTDIR", "from-env")
+	templ := structs.Template{Name: "test", Vars: map[string]string{"OutDir": "dist"}}
+	rendered, err := RenderTemplate(templ, []byte("{{ .OutDir }}/\n"), Options{Target: target})
+	if err != nil || string(rendered) != "dist/\n" {
+		t.Errorf("Expected the GOGI_ variable to be left out but got %q, %v", string(rendered), err)
+	}
+}
+
+func TestRenderRestricted(t *testing.T) {
+	templ := structs.Template{Name: "test"}
+	opts := Options{Restricted: true, Vars: map[string]string{"Dir": "out", "Big": strings.Repeat("x", MaxRestrictedSize)}}
+
+	content, err := RenderTemplate(templ, []byte("{{ if eq .Dir \"out\" }}{{ .Dir }}/{{ end }}\n"), opts)
+	if err != nil || string(content) != "out/\n" {
+		t.Errorf("Expected fields, if and comparisons to be allowed but got %q, %v", string(content), err)
+	}
+	for _, content := range []string{"{{ range .Dir }}x{{ end }}\n", "{{ with .Dir }}x{{ end }}\n", "{{ len .Dir }}\n", "{{ .Big }}\n"} {
+		if _, err := RenderTemplate(templ, []byte(content), opts); err == nil {
+			t.Errorf("Expected %q to be rejected in restricted mode", content)
+		}
+	}
+}
+
+func TestRenderIncludes(t *testing.T) {
+	templates := map[string]string{
+		"common":  "# common\n.env\n*.log\n",
+		"editors": "#gogi:include common\n.idea/\n.vscode/\n",
+		"base-go": "#gogi:include common\n*.exe\n*.test\nvendor/\n",
+		"cycle-a": "#gogi:include cycle-b\n",
+		"cycle-b": "#gogi:include cycle-c\n",
+		"cycle-c": "#gogi:include cycle-a\n",
+		"windows": "#gogi:if os=windows\nThumbs.db\n#gogi:endif\n",
+	}
+	load := func(name string) (structs.Template, []byte, error) {
+		content, ok := templates[name]
+		if !ok {
+			return structs.Template{}, nil, fmt.Errorf("template not found")
+		}
+		return structs.Template{Name: name}, []byte(content), nil
+	}
+
+	tests := []struct {
+		name            string
+		content         string
+		expectedContent string
+		expectedErr     string
+	}{
+		{"include", "#gogi:include common\nbin/\n", "# common\n.env\n*.log\nbin/\n", ""},
+		{"nested include is deduped", "#gogi:include common editors\n",
+			"# common\n.env\n*.log\n.idea/\n.vscode/\n", ""},
+		{"included patterns already present", "*.log\n#gogi:include common\n", "*.log\n# common\n.env\n", ""},
+		{"extends with drop", "#gogi:extends base-go\n#gogi:drop vendor/\n#gogi:drop *.test\nbuild/\n",
+			"# common\n.env\n*.log\n*.exe\nbuild/\n", ""},
+		{"include under condition", "#gogi:if os=linux\n#gogi:include windows\n#gogi:endif\n*.so\n", "*.so\n", ""},
+		{"included conditions", "#gogi:include windows\n*.so\n", "*.so\n", ""},
+		{"cycle", "#gogi:include cycle-a\n", "",
+			"template 'cycle-c' line 1: include cycle test -> cycle-a -> cycle-b -> cycle-c -> cycle-a"},
+		{"self include", "x\n#gogi:include test\n", "", "include cycle test -> test"},
+		{"missing template", "x\n#gogi:include nope\n", "", "template 'test' line 2: could not include 'nope'"},
+		{"drop without extends", "#gogi:drop *.log\n", "", "template 'test' line 1: #gogi:drop needs a #gogi:extends"},
+		{"drop missing pattern", "#gogi:extends common\n#gogi:drop *.tmp\n", "",
+			"template 'test' line 2: the parent has no pattern '*.tmp' to drop"},
+		{"second extends", "#gogi:extends common\n#gogi:extends editors\n", "", "second #gogi:extends"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates["test"] = tt.content
+			opts := Options{Target: Target{OS: "linux"}, Load: load}
+			content, err := RenderTemplate(structs.Template{Name: "test"}, []byte(tt.content), opts)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q but got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("Expected content %q but got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	generated := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	body := []byte("# >>> gogi:go\n*.exe\n# <<< gogi:go\n")
+	content := WithProvenance(Provenance{
+		Version:   "v1.2.3",
+		Generated: generated,
+		Templates: []string{"go", "macos"},
+		Merge:     true,
+	}, body)
+
+	p, parsedBody, ok, err := ParseProvenance(content)
+	if err != nil || !ok {
+		t.Fatalf("ParseProvenance() = %v, %v", ok, err)
+	}
+	if p.Version != "v1.2.3" || !p.Generated.Equal(generated) || !p.Merge ||
+		strings.Join(p.Templates, ",") != "go,macos" || p.Hash != ContentHash(body) {
+		t.Errorf("Expected the header to round trip but got %+v", p)
+	}
+	if string(parsedBody) != string(body) {
+		t.Errorf("Expected body %q but got %q", string(body), string(parsedBody))
+	}
+
+	later := NewProvenance([]string{"go", "macos"}, true)
+	later.Generated = generated.Add(time.Hour)
+	if p, _, _, _ := ParseProvenance(WithProvenance(later, body)); p.Hash != ContentHash(body) {
+		t.Errorf("Expected the generation time to be left out of the hash but got %s", p.Hash)
+	}
+
+	if _, _, ok, err := ParseProvenance(body); ok || err != nil {
+		t.Errorf("Expected no header but got %v, %v", ok, err)
+	}
+
+	broken := strings.Replace(string(content), "# gogi-hash", "gogi-hash", 1)
+	if _, _, _, err := ParseProvenance([]byte(broken)); err == nil {
+		t.Errorf("Expected an error for a broken header")
+	}
+
+	refreshed, err := RefreshProvenance(append(content, "*.log\n"...), func(names []string) []string {
+		return append(names, "node")
+	})
+	if err != nil {
+		t.Fatalf("RefreshProvenance() error = %v", err)
+	}
+	p, parsedBody, _, _ = ParseProvenance(refreshed)
+	if strings.Join(p.Templates, ",") != "go,macos,node" || p.Hash != ContentHash(parsedBody) {
+		t.Errorf("Expected a refreshed header but got %+v", p)
+	}
 }
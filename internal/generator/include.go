@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/ignore"
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// Loader returns the named template along with its content. It resolves
+// the templates pulled in with #gogi:include and #gogi:extends.
+type Loader func(name string) (structs.Template, []byte, error)
+
+// expansion renders a template along with every template it pulls in
+type expansion struct {
+	opts Options
+	// included holds the lowercased names of the templates already
+	// pulled in, which are included only once
+	included map[string]bool
+}
+
+// dropRule is a #gogi:drop directive removing a pattern of the parent
+type dropRule struct {
+	key  string
+	raw  string
+	line int
+	used bool
+}
+
+// render renders the variables and conditions of templ and resolves its
+// includes. chain lists the templates that led to templ, ending in templ.
+func (e *expansion) render(templ structs.Template, content []byte, chain []string) ([]byte, error) {
+	content, err := renderVariables(templ, content, e.opts)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(string(content), DirectivePrefix) {
+		return content, nil
+	}
+	lines, lineNos, err := applyConditions(templ.Name, content, e.opts.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	var drops []*dropRule
+	for i, line := range lines {
+		directive, args, ok := parseDirective(line)
+		if !ok || directive != "drop" {
+			continue
+		}
+		p, ok := ignore.ParseLine(args, lineNos[i])
+		if !ok {
+			return nil, fmt.Errorf("template '%s' line %d: #gogi:drop needs a pattern", templ.Name, lineNos[i])
+		}
+		drops = append(drops, &dropRule{key: p.Key(), raw: args, line: lineNos[i]})
+	}
+
+	var out []string
+	extendsLine := 0
+	for i, line := range lines {
+		directive, args, ok := parseDirective(line)
+		if !ok {
+			out = append(out, line)
+			continue
+		}
+		lineNo := lineNos[i]
+		switch directive {
+		case "include":
+			names := strings.Fields(args)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("template '%s' line %d: #gogi:include needs a template name", templ.Name, lineNo)
+			}
+			for _, name := range names {
+				included, err := e.pull(templ.Name, lineNo, name, chain, false)
+				if err != nil {
+					return nil, err
+				}
+				out = appendDeduped(out, included)
+			}
+		case "extends":
+			if extendsLine > 0 {
+				return nil, fmt.Errorf("template '%s' line %d: second #gogi:extends, the first is on line %d", templ.Name, lineNo, extendsLine)
+			}
+			extendsLine = lineNo
+			if len(strings.Fields(args)) != 1 {
+				return nil, fmt.Errorf("template '%s' line %d: #gogi:extends needs exactly one template name", templ.Name, lineNo)
+			}
+			parent, err := e.pull(templ.Name, lineNo, args, chain, true)
+			if err != nil {
+				return nil, err
+			}
+			out = appendDeduped(out, dropPatterns(parent, drops))
+		}
+	}
+
+	for _, drop := range drops {
+		if extendsLine == 0 {
+			return nil, fmt.Errorf("template '%s' line %d: #gogi:drop needs a #gogi:extends", templ.Name, drop.line)
+		}
+		if !drop.used {
+			return nil, fmt.Errorf("template '%s' line %d: the parent has no pattern '%s' to drop", templ.Name, drop.line, drop.raw)
+		}
+	}
+	return JoinLines(out), nil
+}
+
+// pull loads and renders the named template for the template at the end
+// of chain. A template that was already included is left out, unless it
+// is extended.
+func (e *expansion) pull(from string, lineNo int, name string, chain []string, extends bool) ([]string, error) {
+	if e.opts.Load == nil {
+		return nil, fmt.Errorf("template '%s' line %d: could not include '%s': no templates to include from", from, lineNo, name)
+	}
+	templ, content, err := e.opts.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s' line %d: could not include '%s': %w", from, lineNo, name, err)
+	}
+	for _, link := range chain {
+		if strings.EqualFold(link, templ.Name) {
+			return nil, fmt.Errorf("template '%s' line %d: include cycle %s", from, lineNo, strings.Join(append(chain, templ.Name), " -> "))
+		}
+	}
+
+	key := strings.ToLower(templ.Name)
+	if e.included[key] && !extends {
+		return nil, nil
+	}
+	e.included[key] = true
+
+	rendered, err := e.render(templ, content, append(chain[:len(chain):len(chain)], templ.Name))
+	if err != nil {
+		return nil, err
+	}
+	return SplitLines(rendered), nil
+}
+
+// dropPatterns leaves out the lines of the parent matching a drop
+func dropPatterns(parent []string, drops []*dropRule) []string {
+	kept := make([]string, 0, len(parent))
+	for i, line := range parent {
+		p, ok := ignore.ParseLine(line, i+1)
+		dropped := false
+		for _, drop := range drops {
+			if ok && p.Key() == drop.key {
+				drop.used = true
+				dropped = true
+			}
+		}
+		if !dropped {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// appendDeduped appends the included lines to out, leaving out the
+// patterns out already has
+func appendDeduped(out, included []string) []string {
+	if len(included) == 0 {
+		return out
+	}
+	content, _ := dedupe(JoinLines(included), ignore.Parse(JoinLines(out)), nil)
+	return append(out, SplitLines(content)...)
+}
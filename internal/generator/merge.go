@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"github.com/SQUASHD/gogi/internal/ignore"
+)
+
+// Options controls how templates are written into a .gitignore file
+type Options struct {
+	// Merge leaves out template lines whose pattern is already covered
+	// by an equivalent pattern in the file
+	Merge bool
+	// Vars are the values of template variables given on the command
+	// line, which take precedence over every other source
+	Vars map[string]string
+	// Prompt asks for the value of a variable that has no value
+	// otherwise. Rendering fails on such variables when it is nil.
+	Prompt func(template, name string) (string, error)
+	// Target is the platform and project the #gogi:if directives of the
+	// templates are evaluated for
+	Target Target
+	// Load resolves the templates pulled in by other templates
+	Load Loader
+	// Header writes a provenance header at the top of generated files
+	Header bool
+	// Record keeps a copy of the generated file for gogi update to merge
+	// from. Files that already have a record keep it up to date anyway.
+	Record bool
+	// Restricted renders templates from authors that are not trusted:
+	// placeholders may only use fields, if actions and comparisons, and
+	// the output of a template is limited to MaxRestrictedSize
+	Restricted bool
+}
+
+// Result describes what was written into a .gitignore file
+type Result struct {
+	// Skipped is the number of duplicate lines left out in merge mode
+	Skipped int
+}
+
+// dedupe drops the lines of content whose pattern is redundant next to
+// the patterns before and after the place content is written. Comments
+// and blank lines are kept. It returns the remaining content along with
+// the number of lines dropped.
+func dedupe(content []byte, before, after []ignore.Pattern) ([]byte, int) {
+	lines := SplitLines(content)
+	kept := make([]string, 0, len(lines))
+	seen := append([]ignore.Pattern{}, before...)
+	skipped := 0
+	for i, line := range lines {
+		p, ok := ignore.ParseLine(line, i+1)
+		if !ok {
+			kept = append(kept, line)
+			continue
+		}
+		if ignore.IsRedundant(p, seen, after) {
+			skipped++
+			continue
+		}
+		seen = append(seen, p)
+		kept = append(kept, line)
+	}
+	return JoinLines(kept), skipped
+}
+
+// surroundingPatterns returns the patterns of content that come before
+// and after the place the named template's block is written: around its
+// current block, or all of them before when the block is new
+func surroundingPatterns(content []byte, name string) ([]ignore.Pattern, []ignore.Pattern, error) {
+	lines := SplitLines(content)
+	blocks, err := ParseBlocks(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, ok := FindBlock(blocks, name)
+	if !ok {
+		return ignore.Parse(content), nil, nil
+	}
+	before := ignore.Parse(JoinLines(lines[:block.Start]))
+	after := ignore.Parse(JoinLines(lines[block.End+1:]))
+	return before, after, nil
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SQUASHD/gogi/internal/version"
+)
+
+const (
+	provenanceIntro  = "# This file was generated by gogi. Run gogi status to check it for drift."
+	provenancePrefix = "# gogi-"
+)
+
+// Provenance is the header gogi writes at the top of a generated
+// .gitignore file to record where it came from
+type Provenance struct {
+	Version string
+	// Generated is when the file was written. It is not part of Hash, so
+	// the file is only compared by what is below the header.
+	Generated time.Time
+	// Templates are the names of the templates in the order written
+	Templates []string
+	// Merge is whether duplicate patterns were left out
+	Merge bool
+	// Hash is the ContentHash of the file below the header
+	Hash string
+}
+
+// NewProvenance returns the provenance of a file generated now by this
+// version of gogi from the named templates
+func NewProvenance(templates []string, merge bool) Provenance {
+	return Provenance{
+		Version:   version.String(),
+		Generated: time.Now(),
+		Templates: templates,
+		Merge:     merge,
+	}
+}
+
+// ContentHash returns the hash recorded for .gitignore content
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Render returns the header lines followed by a blank line
+func (p Provenance) Render() []byte {
+	var sb strings.Builder
+	sb.WriteString(provenanceIntro + "\n")
+	sb.WriteString(provenancePrefix + "version: " + p.Version + "\n")
+	sb.WriteString(provenancePrefix + "generated: " + p.Generated.UTC().Format(time.RFC3339) + "\n")
+	sb.WriteString(provenancePrefix + "templates: " + strings.Join(p.Templates, ", ") + "\n")
+	if p.Merge {
+		sb.WriteString(provenancePrefix + "options: merge\n")
+	}
+	sb.WriteString(provenancePrefix + "hash: " + p.Hash + "\n")
+	sb.WriteString("\n")
+	return []byte(sb.String())
+}
+
+// WithProvenance puts a header recording p in front of body, hashing body
+func WithProvenance(p Provenance, body []byte) []byte {
+	p.Hash = ContentHash(body)
+	return append(p.Render(), body...)
+}
+
+// ParseProvenance reads the header at the top of a .gitignore file. It
+// returns the header and the content below it, and reports whether the
+// file has a header at all.
+func ParseProvenance(content []byte) (Provenance, []byte, bool, error) {
+	var p Provenance
+	text := string(content)
+	if !strings.HasPrefix(text, provenanceIntro+"\n") {
+		return p, content, false, nil
+	}
+	rest := strings.TrimPrefix(text, provenanceIntro+"\n")
+
+	for lineNo := 2; ; lineNo++ {
+		line, remaining, found := strings.Cut(rest, "\n")
+		if !found {
+			return p, nil, true, fmt.Errorf("line %d: the gogi header is not followed by a blank line", lineNo)
+		}
+		rest = remaining
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, provenancePrefix), ": ")
+		if !strings.HasPrefix(line, provenancePrefix) || !ok {
+			return p, nil, true, fmt.Errorf("line %d: invalid gogi header line '%s'", lineNo, line)
+		}
+		switch key {
+		case "version":
+			p.Version = value
+		case "generated":
+			generated, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return p, nil, true, fmt.Errorf("line %d: invalid generation time: %w", lineNo, err)
+			}
+			p.Generated = generated
+		case "templates":
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					p.Templates = append(p.Templates, name)
+				}
+			}
+		case "options":
+			p.Merge = strings.Contains(value, "merge")
+		case "hash":
+			p.Hash = value
+		}
+	}
+	if p.Hash == "" {
+		return p, nil, true, fmt.Errorf("the gogi header has no hash")
+	}
+	return p, []byte(rest), true, nil
+}
+
+// RefreshProvenance updates the header of content after its templates
+// changed, recording the templates returned by edit along with the new
+// hash and generation time. Content without a header is returned as is.
+func RefreshProvenance(content []byte, edit func(templates []string) []string) ([]byte, error) {
+	p, body, ok, err := ParseProvenance(content)
+	if err != nil || !ok {
+		return content, err
+	}
+	p.Templates = edit(p.Templates)
+	p.Generated = time.Now()
+	return WithProvenance(p, body), nil
+}
@@ -0,0 +1,264 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// Variable is a placeholder such as {{ .BuildDir }} used by a template
+type Variable struct {
+	Name string
+	// Line is the template line the variable is first used on
+	Line int
+}
+
+// ReadTemplate reads the file of templ and renders its placeholders
+func ReadTemplate(templ structs.Template, opts Options) ([]byte, error) {
+	content, err := os.ReadFile(templ.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open template file: %w", err)
+	}
+	return RenderTemplate(templ, content, opts)
+}
+
+// RenderTemplate fills in the placeholders of a template, keeps the
+// lines whose #gogi:if conditions hold for opts.Target and then replaces
+// the #gogi:include and #gogi:extends directives with the rendered
+// templates they name, loaded with opts.Load.
+//
+// Included templates are pulled in once, and their patterns that are
+// already in the output are left out. A template extending another can
+// remove patterns of its parent with #gogi:drop pattern.
+func RenderTemplate(templ structs.Template, content []byte, opts Options) ([]byte, error) {
+	e := &expansion{
+		opts:     opts,
+		included: map[string]bool{strings.ToLower(templ.Name): true},
+	}
+	return e.render(templ, content, []string{templ.Name})
+}
+
+// renderVariables fills in the placeholders of a template. Templates
+// without placeholders are returned as they are. The value of a variable
+// comes from opts.Vars, the GOGI_<NAME> environment variable unless
+// opts.Target.NoEnv is set, the defaults of the template or opts.Prompt,
+// in that order.
+func renderVariables(templ structs.Template, content []byte, opts Options) ([]byte, error) {
+	if !bytes.Contains(content, []byte("{{")) {
+		return content, nil
+	}
+
+	tmpl, err := template.New(templ.Name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("could not render template '%s': %w", templ.Name, err)
+	}
+
+	if opts.Restricted {
+		if err := checkRestricted(tmpl.Tree); err != nil {
+			return nil, fmt.Errorf("could not render template '%s': %w", templ.Name, err)
+		}
+	}
+
+	values := make(map[string]string)
+	for _, variable := range Variables(tmpl.Tree, content) {
+		value, err := lookupVariable(templ, variable, opts)
+		if err != nil {
+			return nil, err
+		}
+		values[variable.Name] = value
+	}
+
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	if opts.Restricted {
+		out = &limitedWriter{w: &buf, remaining: MaxRestrictedSize}
+	}
+	if err := tmpl.Execute(out, values); err != nil {
+		return nil, fmt.Errorf("could not render template '%s': %w", templ.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MaxRestrictedSize limits the output of a template rendered with
+// Options.Restricted
+const MaxRestrictedSize = 1 << 20
+
+// restrictedFuncs are the template functions allowed in restricted mode
+var restrictedFuncs = map[string]bool{
+	"and": true, "or": true, "not": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+}
+
+// checkRestricted rejects the actions of tree that restricted mode does
+// not allow: range, with, template and every function other than the
+// comparisons, since they can loop or grow the output without bound
+func checkRestricted(tree *parse.Tree) error {
+	var err error
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		if err != nil {
+			return
+		}
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			err = fmt.Errorf("range is not allowed")
+		case *parse.WithNode:
+			err = fmt.Errorf("with is not allowed")
+		case *parse.TemplateNode:
+			err = fmt.Errorf("template is not allowed")
+		case *parse.IdentifierNode:
+			if !restrictedFuncs[n.Ident] {
+				err = fmt.Errorf("function %s is not allowed", n.Ident)
+			}
+		}
+	}
+	if tree != nil {
+		walk(tree.Root)
+	}
+	return err
+}
+
+// limitedWriter fails once more than remaining bytes are written
+type limitedWriter struct {
+	w         io.Writer
+	remaining int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > l.remaining {
+		return 0, fmt.Errorf("rendered template is larger than %d bytes", MaxRestrictedSize)
+	}
+	l.remaining -= len(p)
+	return l.w.Write(p)
+}
+
+// ListVariables returns the variables used by the template content
+func ListVariables(name string, content []byte) ([]Variable, error) {
+	if !bytes.Contains(content, []byte("{{")) {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template '%s': %w", name, err)
+	}
+	return Variables(tmpl.Tree, content), nil
+}
+
+// Variables returns the variables used in tree in order of first use.
+// Fields used inside range and with actions refer to another value and
+// are not variables of the template.
+func Variables(tree *parse.Tree, content []byte) []Variable {
+	var variables []Variable
+	seen := make(map[string]bool)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.ElseList)
+		case *parse.FieldNode:
+			name := n.Ident[0]
+			if !seen[name] {
+				seen[name] = true
+				variables = append(variables, Variable{Name: name, Line: lineAt(content, int(n.Position()))})
+			}
+		}
+	}
+	if tree != nil {
+		walk(tree.Root)
+	}
+	return variables
+}
+
+// lookupVariable finds the value of a variable used by templ
+func lookupVariable(templ structs.Template, variable Variable, opts Options) (string, error) {
+	if value, ok := opts.Vars[variable.Name]; ok {
+		return value, nil
+	}
+	if value, ok := os.LookupEnv(EnvVariable(variable.Name)); ok && !opts.Target.NoEnv {
+		return value, nil
+	}
+	if value, ok := templ.Vars[variable.Name]; ok {
+		return value, nil
+	}
+	if opts.Prompt != nil {
+		value, err := opts.Prompt(templ.Name, variable.Name)
+		if err != nil {
+			return "", fmt.Errorf("template '%s' line %d: could not read %s: %w", templ.Name, variable.Line, variable.Name, err)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("template '%s' line %d: no value for %s. try --set %s=value",
+		templ.Name, variable.Line, variable.Name, variable.Name)
+}
+
+// EnvVariable returns the environment variable that sets the named
+// template variable, such as GOGI_BUILDDIR for BuildDir
+func EnvVariable(name string) string {
+	return "GOGI_" + strings.ToUpper(name)
+}
+
+// lineAt returns the line number of the byte offset within content
+func lineAt(content []byte, offset int) int {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
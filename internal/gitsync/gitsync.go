@@ -0,0 +1,263 @@
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// remoteName is the name gogi gives the configured remote
+const remoteName = "origin"
+
+// ErrDiverged is returned by Pull when local and remote changes have to
+// be merged by hand
+var ErrDiverged = errors.New("local and remote templates have diverged")
+
+// Repo is a template directory managed as a git repository
+type Repo struct {
+	Dir    string
+	Branch string
+}
+
+// Init turns dir into a git repository, when it is not one already, and
+// points its remote at url. Files in ignored, such as caches, are added to
+// the .gitignore file of dir and kept out of the repository.
+func Init(dir, url, branch string, ignored ...string) (*Repo, error) {
+	repo := &Repo{Dir: dir, Branch: branch}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := repo.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+		if _, err := repo.git("symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := repo.git("remote", "get-url", remoteName); err != nil {
+		_, err = repo.git("remote", "add", remoteName, url)
+		if err != nil {
+			return nil, err
+		}
+	} else if _, err := repo.git("remote", "set-url", remoteName, url); err != nil {
+		return nil, err
+	}
+
+	if len(ignored) > 0 {
+		if err := ignoreFiles(dir, ignored); err != nil {
+			return nil, err
+		}
+		args := append([]string{"rm", "-r", "--cached", "--quiet", "--ignore-unmatch", "--"}, ignored...)
+		if _, err := repo.git(args...); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// ignoreFiles adds the entries missing from the .gitignore file of dir,
+// keeping the lines already there
+func ignoreFiles(dir string, entries []string) error {
+	path := filepath.Join(dir, ".gitignore")
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to read .gitignore file: %w", err)
+	}
+	existing := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	added := false
+	for _, entry := range entries {
+		if existing[entry] {
+			continue
+		}
+		if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			content = append(content, '\n')
+		}
+		content = append(content, entry+"\n"...)
+		existing[entry] = true
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("unable to write .gitignore file: %w", err)
+	}
+	return nil
+}
+
+// Open returns the repository at dir, failing when dir is not managed by git
+func Open(dir, branch string) (*Repo, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil, fmt.Errorf("the template directory is not a git repository. try gogi repo remote-url")
+	}
+	return &Repo{Dir: dir, Branch: branch}, nil
+}
+
+// Push commits every change in the template directory with a generated
+// message and pushes it to the remote. It returns the commit message, or
+// "" when there was nothing to commit.
+func (r *Repo) Push() (string, error) {
+	if _, err := r.git("add", "--all"); err != nil {
+		return "", err
+	}
+	status, err := r.git("status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+
+	message := ""
+	if strings.TrimSpace(status) != "" {
+		message = commitMessage(status)
+		if _, err := r.git("commit", "--quiet", "-m", message); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := r.git("push", "--quiet", remoteName, "HEAD:refs/heads/"+r.Branch); err != nil {
+		return "", fmt.Errorf("push was rejected, try gogi pull first: %w", err)
+	}
+	return message, nil
+}
+
+// Pull fast-forwards the template directory to the remote branch. When
+// both sides have new commits it returns ErrDiverged along with the
+// files changed on both sides.
+func (r *Repo) Pull() ([]string, error) {
+	if _, err := r.git("fetch", "--quiet", remoteName); err != nil {
+		return nil, err
+	}
+	remoteRef := remoteName + "/" + r.Branch
+	if _, err := r.git("rev-parse", "--verify", "--quiet", remoteRef); err != nil {
+		return nil, nil
+	}
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, r.adopt(remoteRef)
+	}
+
+	if _, err := r.git("merge", "--ff-only", "--quiet", remoteRef); err == nil {
+		return nil, nil
+	}
+
+	base, err := r.git("merge-base", "HEAD", remoteRef)
+	if err != nil {
+		return nil, err
+	}
+	base = strings.TrimSpace(base)
+	local, err := r.changedFiles(base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.changedFiles(base, remoteRef)
+	if err != nil {
+		return nil, err
+	}
+	if uncommitted, err := r.git("status", "--porcelain"); err == nil {
+		for _, line := range strings.Split(strings.TrimRight(uncommitted, "\n"), "\n") {
+			if len(line) > 3 {
+				local[strings.TrimSpace(line[3:])] = true
+			}
+		}
+	}
+
+	var conflicts []string
+	for file := range remote {
+		if local[file] {
+			conflicts = append(conflicts, file)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts, ErrDiverged
+}
+
+// adopt points a repository without commits at ref. Files already in the
+// directory, such as the .gitignore file written by Init, are kept as
+// local changes and only the missing files are checked out.
+func (r *Repo) adopt(ref string) error {
+	if _, err := r.git("reset", "--quiet", ref); err != nil {
+		return err
+	}
+	deleted, err := r.git("ls-files", "-z", "--deleted")
+	if err != nil {
+		return err
+	}
+	files := strings.Split(strings.TrimSuffix(deleted, "\x00"), "\x00")
+	if deleted == "" {
+		return nil
+	}
+	_, err = r.git(append([]string{"checkout", "--"}, files...)...)
+	return err
+}
+
+// changedFiles returns the files changed between two commits
+func (r *Repo) changedFiles(from, to string) (map[string]bool, error) {
+	out, err := r.git("diff", "--name-only", from, to)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	for _, file := range strings.Fields(out) {
+		files[file] = true
+	}
+	return files, nil
+}
+
+// git runs a git command in the template directory
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// commitMessage summarizes the changes listed by git status --porcelain
+func commitMessage(status string) string {
+	var added, modified, deleted []string
+	for _, line := range strings.Split(strings.TrimRight(status, "\n"), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		file := strings.TrimSpace(line[3:])
+		if i := strings.Index(file, " -> "); i >= 0 {
+			file = file[i+4:]
+		}
+		name := file
+		if name != ".gitignore" {
+			name = strings.TrimSuffix(name, ".gitignore")
+		}
+		switch line[0] {
+		case 'A', '?':
+			added = append(added, name)
+		case 'D':
+			deleted = append(deleted, name)
+		default:
+			modified = append(modified, name)
+		}
+	}
+
+	var parts []string
+	for _, part := range []struct {
+		verb  string
+		files []string
+	}{{"add", added}, {"update", modified}, {"delete", deleted}} {
+		if len(part.files) > 0 {
+			parts = append(parts, part.verb+" "+strings.Join(part.files, ", "))
+		}
+	}
+	return "gogi: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,164 @@
+package gitsync
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setupGit(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "gogi")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gogi@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gogi")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gogi@example.com")
+
+	remote := filepath.Join(t.TempDir(), "templates.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v: %s", err, out)
+	}
+	return remote
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestPushAndPull(t *testing.T) {
+	remote := setupGit(t)
+	laptop, desktop := t.TempDir(), t.TempDir()
+
+	first, err := Init(laptop, remote, "main", "cache/")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	writeFile(t, laptop, "go.gitignore", "*.exe\n")
+	writeFile(t, laptop, "config.json", "{}\n")
+	message, err := first.Push()
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if message != "gogi: add .gitignore, config.json, go" {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+
+	second, err := Init(desktop, remote, "main")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, err := second.Pull(); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readFile(t, desktop, "go.gitignore"); got != "*.exe\n" {
+		t.Errorf("Expected pulled template but got %q", got)
+	}
+
+	writeFile(t, desktop, "go.gitignore", "*.exe\nvendor/\n")
+	if message, err = second.Push(); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if message != "gogi: update go" {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+	if _, err := first.Pull(); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got := readFile(t, laptop, "go.gitignore"); got != "*.exe\nvendor/\n" {
+		t.Errorf("Expected fast-forwarded template but got %q", got)
+	}
+
+	if message, err = first.Push(); err != nil || message != "" {
+		t.Errorf("Expected nothing to commit but got %q, %v", message, err)
+	}
+}
+
+func TestInitKeepsIgnores(t *testing.T) {
+	remote := setupGit(t)
+	dir := t.TempDir()
+	writeFile(t, dir, ".gitignore", "*.swp")
+	writeFile(t, dir, "config.json", "{}\n")
+
+	repo, err := Init(dir, remote, "main")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, err := repo.Push(); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Init(dir, remote, "main", "cache/", "config.json"); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+	}
+	if got := readFile(t, dir, ".gitignore"); got != "*.swp\ncache/\nconfig.json\n" {
+		t.Errorf("Expected the entries to be added to .gitignore but got %q", got)
+	}
+	if tracked, _ := repo.git("ls-files"); tracked != ".gitignore\n" {
+		t.Errorf("Expected config.json to no longer be tracked but got %q", tracked)
+	}
+	if got := readFile(t, dir, "config.json"); got != "{}\n" {
+		t.Errorf("Expected config.json to be kept on disk but got %q", got)
+	}
+}
+
+func TestPullReportsConflicts(t *testing.T) {
+	remote := setupGit(t)
+	laptop, desktop := t.TempDir(), t.TempDir()
+
+	first, _ := Init(laptop, remote, "main")
+	writeFile(t, laptop, "go.gitignore", "*.exe\n")
+	writeFile(t, laptop, "node.gitignore", "node_modules/\n")
+	if _, err := first.Push(); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	second, _ := Init(desktop, remote, "main")
+	if _, err := second.Pull(); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	writeFile(t, laptop, "go.gitignore", "*.exe\n*.test\n")
+	if _, err := first.Push(); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	writeFile(t, desktop, "go.gitignore", "*.exe\nbin/\n")
+	writeFile(t, desktop, "node.gitignore", "node_modules/\ndist/\n")
+	if _, err := second.Push(); err == nil {
+		t.Fatalf("Expected push to be rejected when the remote is ahead")
+	}
+
+	conflicts, err := second.Pull()
+	if !errors.Is(err, ErrDiverged) {
+		t.Fatalf("Expected ErrDiverged but got %v", err)
+	}
+	if !reflect.DeepEqual(conflicts, []string{"go.gitignore"}) {
+		t.Errorf("Expected conflicts [go.gitignore] but got %v", conflicts)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open(t.TempDir(), "main"); err == nil {
+		t.Errorf("Expected an error for a directory that is not a repository")
+	}
+}
@@ -0,0 +1,286 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		ok      bool
+		key     string
+		negate  bool
+		dirOnly bool
+	}{
+		{"blank line", "", false, "", false, false},
+		{"only spaces", "   ", false, "", false, false},
+		{"comment", "# comment", false, "", false, false},
+		{"escaped hash", "\\#file", true, "\\#file", false, false},
+		{"plain name", "*.log", true, "*.log", false, false},
+		{"trailing spaces are trimmed", "*.log  ", true, "*.log", false, false},
+		{"escaped trailing space is kept", "file\\ ", true, "file\\ ", false, false},
+		{"directory only", "build/", true, "build/", false, true},
+		{"anchored by leading slash", "/build/", true, "/build/", false, true},
+		{"anchored by middle slash", "a/b", true, "/a/b", false, false},
+		{"leading slash on anchored path", "/a/b", true, "/a/b", false, false},
+		{"leading double star", "**/foo", true, "foo", false, false},
+		{"leading double star with path", "**/foo/bar", true, "/**/foo/bar", false, false},
+		{"negation", "!keep.log", true, "!keep.log", true, false},
+		{"escaped negation", "\\!important", true, "\\!important", false, false},
+		{"carriage return", "*.tmp\r", true, "*.tmp", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := ParseLine(tt.line, 1)
+			if ok != tt.ok {
+				t.Fatalf("ParseLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if p.Key() != tt.key {
+				t.Errorf("Expected key %q but got %q", tt.key, p.Key())
+			}
+			if p.Negate != tt.negate {
+				t.Errorf("Expected negate %v but got %v", tt.negate, p.Negate)
+			}
+			if p.DirOnly != tt.dirOnly {
+				t.Errorf("Expected dir only %v but got %v", tt.dirOnly, p.DirOnly)
+			}
+		})
+	}
+}
+
+func TestIsRedundant(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		pattern  string
+		after    string
+		expected bool
+	}{
+		{"no other patterns", "", "*.log", "", false},
+		{"exact duplicate before", "*.log\n", "*.log", "", true},
+		{"equivalent duplicate before", "/a/b\n", "a/b", "", true},
+		{"differently anchored", "/build/\n", "build/", "", false},
+		{"negation in between", "*.log\n!keep.log\n", "*.log", "", false},
+		{"same polarity in between", "*.log\n*.tmp\n", "*.log", "", true},
+		{"duplicate after", "!keep.log\n", "*.log", "*.log\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := ParseLine(tt.pattern, 1)
+			got := IsRedundant(p, Parse([]byte(tt.before)), Parse([]byte(tt.after)))
+			if got != tt.expected {
+				t.Errorf("IsRedundant() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWildmatch(t *testing.T) {
+	tests := []struct {
+		glob     string
+		text     string
+		expected bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"*.log", "debug.log", true},
+		{"*.log", "a/debug.log", false},
+		{"?oo", "foo", true},
+		{"?oo", "/oo", false},
+		{"foo/*", "foo/bar", true},
+		{"foo/*", "foo/bar/baz", false},
+		{"**/foo", "foo", true},
+		{"**/foo", "a/b/foo", true},
+		{"abc/**", "abc/x", true},
+		{"abc/**", "abc/x/y", true},
+		{"abc/**", "abc", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/xb", false},
+		{"a**b", "axb", true},
+		{"a**b", "a/b", false},
+		{"[abc].txt", "b.txt", true},
+		{"[abc].txt", "d.txt", false},
+		{"[!abc].txt", "d.txt", true},
+		{"[^abc].txt", "a.txt", false},
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[]]", "]", true},
+		{"[a-]", "-", true},
+		{"[[:digit:]]*", "1abc", true},
+		{"[[:digit:]]*", "abc", false},
+		{"[[:upper:][:digit:]]", "Q", true},
+		{"a[/]b", "a/b", false},
+		{"[abc", "a", false},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"file\\ ", "file ", true},
+		{"\\!important", "!important", true},
+		{"\\#file", "#file", true},
+		{"*", "", true},
+		{"foo*bar", "foobazbar", true},
+		{"foo*bar", "foo/bar", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.text, func(t *testing.T) {
+			if got := wildmatch(tt.glob, tt.text); got != tt.expected {
+				t.Errorf("wildmatch(%q, %q) = %v, want %v", tt.glob, tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	type file struct {
+		dir     string
+		content string
+	}
+	tests := []struct {
+		name     string
+		files    []file
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		// examples from the gitignore documentation
+		{"name matches at any level", []file{{"", "hello.*\n"}}, "a/hello.java", false, true},
+		{"leading slash anchors", []file{{"", "/hello.*\n"}}, "hello.txt", false, true},
+		{"leading slash anchors not below", []file{{"", "/hello.*\n"}}, "a/hello.java", false, false},
+		{"middle slash anchors", []file{{"", "doc/frotz/\n"}}, "doc/frotz", true, true},
+		{"middle slash anchors not below", []file{{"", "doc/frotz/\n"}}, "a/doc/frotz", true, false},
+		{"directory only matches directories", []file{{"", "frotz/\n"}}, "a/frotz", true, true},
+		{"directory only skips files", []file{{"", "frotz/\n"}}, "a/frotz", false, false},
+		{"trailing slash marks a directory", []file{{"", "frotz/\n"}}, "frotz/", false, true},
+		{"star does not cross slash", []file{{"", "foo/*\n"}}, "foo/test.json", false, true},
+		{"contents of ignored directory", []file{{"", "foo/*\n"}}, "foo/bar/hello.c", false, true},
+		{"leading double star", []file{{"", "**/foo\n"}}, "x/y/foo", false, true},
+		{"leading double star with path", []file{{"", "**/foo/bar\n"}}, "x/foo/bar", false, true},
+		{"leading double star with path at root", []file{{"", "**/foo/bar\n"}}, "foo/bar", false, true},
+		{"trailing double star", []file{{"", "abc/**\n"}}, "abc/d/e", false, true},
+		{"middle double star", []file{{"", "a/**/b\n"}}, "a/x/y/b", false, true},
+		{"escaped trailing space", []file{{"", "file\\ \n"}}, "file ", false, true},
+		{"trailing space is trimmed", []file{{"", "file \n"}}, "file", false, true},
+		{"character class", []file{{"", "*.[oa]\n"}}, "lib.a", false, true},
+		{"no pattern matches", []file{{"", "*.log\n"}}, "main.go", false, false},
+
+		// negation
+		{"negation re-includes", []file{{"", "*.log\n!keep.log\n"}}, "keep.log", false, false},
+		{"last pattern wins", []file{{"", "!keep.log\n*.log\n"}}, "keep.log", false, true},
+		{"negation can not re-include below excluded directory", []file{{"", "build/\n!build/keep.txt\n"}}, "build/keep.txt", false, true},
+		{"only foo/bar is kept", []file{{"", "/*\n!/foo\n/foo/*\n!/foo/bar\n"}}, "foo/bar", true, false},
+		{"only foo/bar is kept siblings ignored", []file{{"", "/*\n!/foo\n/foo/*\n!/foo/bar\n"}}, "foo/baz", false, true},
+		{"escaped negation is literal", []file{{"", "\\!important\n"}}, "!important", false, true},
+
+		// nested files
+		{"nested file overrides parent", []file{{"", "*.log\n"}, {"sub", "!important.log\n"}}, "sub/important.log", false, false},
+		{"nested file does not apply outside", []file{{"", "*.log\n"}, {"sub", "!important.log\n"}}, "important.log", false, true},
+		{"nested patterns are relative", []file{{"sub", "/out\n"}}, "sub/out", false, true},
+		{"nested anchored pattern not below", []file{{"sub", "/out\n"}}, "sub/a/out", false, false},
+		{"earlier file has lower precedence", []file{{"", "!*.log\n"}, {"", "*.log\n"}}, "a.log", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []*File
+			for _, f := range tt.files {
+				files = append(files, NewFile(".gitignore", f.dir, []byte(f.content)))
+			}
+			m := NewMatcher(files...)
+			if got := m.Ignored(tt.path, tt.isDir); got != tt.expected {
+				t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchReportsDecidingPattern(t *testing.T) {
+	m := NewMatcher(NewFile(".gitignore", "", []byte("# logs\n*.log\n!keep.log\nbuild/\n")))
+
+	tests := []struct {
+		path         string
+		expectedLine int
+		expectedPath string
+	}{
+		{"debug.log", 2, "debug.log"},
+		{"keep.log", 3, "keep.log"},
+		{"build/keep.log", 4, "build"},
+		{"main.go", 0, "main.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			match := m.Match(tt.path, false)
+			line := 0
+			if match.Pattern != nil {
+				line = match.Pattern.Line
+			}
+			if line != tt.expectedLine {
+				t.Errorf("Expected deciding line %d but got %d", tt.expectedLine, line)
+			}
+			if match.Path != tt.expectedPath {
+				t.Errorf("Expected deciding path %q but got %q", tt.expectedPath, match.Path)
+			}
+		})
+	}
+}
+
+func TestRepositoryFiles(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+
+	files := map[string]string{
+		"xdg/git/ignore":    "*.swp\n",
+		".git/info/exclude": "local/\n",
+		".gitignore":        "*.log\n",
+		"a/.gitignore":      "!keep.log\n",
+		"a/b/.gitignore":    "*.tmp\n",
+		"c/.gitignore":      "*.bin\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	found, err := FindRoot(filepath.Join(root, "a", "b"))
+	if err != nil || found != root {
+		t.Fatalf("FindRoot() = %q, %v, want %q", found, err, root)
+	}
+
+	got, err := RepositoryFiles(root, "a/b/keep.log")
+	if err != nil {
+		t.Fatalf("RepositoryFiles() error = %v", err)
+	}
+	expectedDirs := []string{"", "", "", "a", "a/b"}
+	if len(got) != len(expectedDirs) {
+		t.Fatalf("Expected %d files but got %d", len(expectedDirs), len(got))
+	}
+	for i, file := range got {
+		if file.Dir != expectedDirs[i] {
+			t.Errorf("Expected file %d to apply to %q but got %q", i, expectedDirs[i], file.Dir)
+		}
+	}
+
+	m := NewMatcher(got...)
+	if m.Ignored("a/b/keep.log", false) {
+		t.Errorf("Expected a/b/keep.log to be re-included by a/.gitignore")
+	}
+	if !m.Ignored("a/b/x.swp", false) {
+		t.Errorf("Expected a/b/x.swp to be ignored by the global excludes file")
+	}
+}
@@ -0,0 +1,131 @@
+package ignore
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// File holds the patterns read from one gitignore file
+type File struct {
+	// Source names where the patterns were read from, such as the path
+	// of the file, and is reported along with a deciding pattern
+	Source string
+	// Dir is the directory the patterns are relative to, given relative
+	// to the root of the tree with '/' separators and "" for the root
+	Dir      string
+	Patterns []Pattern
+}
+
+// NewFile parses the content of a gitignore file whose patterns apply
+// to the tree below dir
+func NewFile(source, dir string, content []byte) *File {
+	return &File{
+		Source:   source,
+		Dir:      strings.Trim(path.Clean("/"+dir), "/"),
+		Patterns: Parse(content),
+	}
+}
+
+// ReadFile reads and parses the gitignore file at filePath
+func ReadFile(filePath, dir string) (*File, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", filePath, err)
+	}
+	return NewFile(filePath, dir, content), nil
+}
+
+// Match is the outcome of checking a path against a Matcher
+type Match struct {
+	// Ignored reports whether the path is ignored
+	Ignored bool
+	// Pattern is the pattern that decided, nil when no pattern matched
+	Pattern *Pattern
+	// File is the file the deciding pattern was read from
+	File *File
+	// Path is the path the deciding pattern matched. It differs from the
+	// checked path when a parent directory is excluded, since git does
+	// not look inside excluded directories.
+	Path string
+}
+
+// Matcher decides which paths of a tree are ignored by a set of
+// gitignore files
+type Matcher struct {
+	files []*File
+}
+
+// NewMatcher compiles the given files into a Matcher. Files given later
+// take precedence over the ones before them, so they should be ordered
+// from the global excludes file through .git/info/exclude and the root
+// .gitignore down to the most nested .gitignore files.
+func NewMatcher(files ...*File) *Matcher {
+	return &Matcher{files: files}
+}
+
+// Match checks the path, given relative to the root of the tree with '/'
+// separators. A trailing '/' marks the path as a directory, as does isDir.
+func (m *Matcher) Match(name string, isDir bool) Match {
+	if strings.HasSuffix(name, "/") {
+		isDir = true
+	}
+	name = strings.Trim(path.Clean("/"+name), "/")
+
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		parent := strings.Join(parts[:i], "/")
+		if match := m.matchPath(parent, true); match.Ignored {
+			return match
+		}
+	}
+	return m.matchPath(name, isDir)
+}
+
+// Ignored reports whether the path is ignored
+func (m *Matcher) Ignored(name string, isDir bool) bool {
+	return m.Match(name, isDir).Ignored
+}
+
+// matchPath finds the deciding pattern for the path itself, without
+// looking at its parent directories
+func (m *Matcher) matchPath(name string, isDir bool) Match {
+	for i := len(m.files) - 1; i >= 0; i-- {
+		file := m.files[i]
+		rel, ok := relativeTo(file.Dir, name)
+		if !ok {
+			continue
+		}
+		for j := len(file.Patterns) - 1; j >= 0; j-- {
+			p := &file.Patterns[j]
+			if p.Match(rel, isDir) {
+				return Match{Ignored: !p.Negate, Pattern: p, File: file, Path: name}
+			}
+		}
+	}
+	return Match{Path: name}
+}
+
+// Match reports whether the pattern matches the path, given relative to
+// the directory of the pattern's gitignore file
+func (p Pattern) Match(name string, isDir bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+	if !p.Anchored {
+		return wildmatch(p.Glob, path.Base(name))
+	}
+	return wildmatch(p.Glob, name)
+}
+
+// relativeTo returns name relative to dir and whether name lies below dir
+func relativeTo(dir, name string) (string, bool) {
+	if dir == "" {
+		return name, true
+	}
+	if !strings.HasPrefix(name, dir+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(name, dir+"/"), true
+}
@@ -0,0 +1,140 @@
+package ignore
+
+import (
+	"strings"
+)
+
+// Pattern is a single rule of a gitignore file
+type Pattern struct {
+	// Raw is the line as it was written in the file
+	Raw string
+	// Line is the 1-based line number the pattern was read from
+	Line int
+	// Negate is set for patterns starting with '!' that re-include paths
+	Negate bool
+	// DirOnly is set for patterns ending in '/' that only match directories
+	DirOnly bool
+	// Anchored is set for patterns that are matched relative to the
+	// directory of the gitignore file rather than at any depth
+	Anchored bool
+	// Glob is the pattern without its leading '!' or '/' and trailing '/'
+	Glob string
+}
+
+// ParseLine parses one line of a gitignore file. It reports false for
+// blank lines and comments, which hold no pattern.
+func ParseLine(line string, lineNo int) (Pattern, bool) {
+	p := Pattern{Raw: line, Line: lineNo}
+
+	text := strings.TrimSuffix(line, "\r")
+	if strings.HasPrefix(text, "#") {
+		return p, false
+	}
+	text = trimTrailingSpaces(text)
+	if text == "" {
+		return p, false
+	}
+
+	if strings.HasPrefix(text, "!") {
+		p.Negate = true
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "/") && !isEscaped(text[:len(text)-1]) {
+		p.DirOnly = true
+		text = strings.TrimSuffix(text, "/")
+	}
+	if strings.Contains(text, "/") {
+		p.Anchored = true
+		text = strings.TrimPrefix(text, "/")
+	}
+	// a leading **/ before a single name matches in all directories, just
+	// like a pattern without any slash
+	for strings.HasPrefix(text, "**/") && !strings.Contains(text[3:], "/") {
+		text = strings.TrimPrefix(text, "**/")
+		p.Anchored = false
+	}
+	for strings.Contains(text, "/**/**/") {
+		text = strings.ReplaceAll(text, "/**/**/", "/**/")
+	}
+	if text == "" {
+		return p, false
+	}
+
+	p.Glob = text
+	return p, true
+}
+
+// Parse parses the content of a gitignore file into its patterns
+func Parse(content []byte) []Pattern {
+	var patterns []Pattern
+	for i, line := range strings.Split(string(content), "\n") {
+		if p, ok := ParseLine(line, i+1); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// Key returns a normalized form of the pattern. Two patterns with the
+// same key match exactly the same paths, so the later one of them can be
+// dropped as long as no pattern of the other polarity sits in between.
+func (p Pattern) Key() string {
+	var sb strings.Builder
+	if p.Negate {
+		sb.WriteString("!")
+	}
+	if p.Anchored {
+		sb.WriteString("/")
+	}
+	sb.WriteString(p.Glob)
+	if p.DirOnly {
+		sb.WriteString("/")
+	}
+	return sb.String()
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped
+// with a backslash
+func trimTrailingSpaces(text string) string {
+	for strings.HasSuffix(text, " ") {
+		trimmed := text[:len(text)-1]
+		if isEscaped(trimmed) {
+			break
+		}
+		text = trimmed
+	}
+	return text
+}
+
+// isEscaped reports whether the character following text is escaped,
+// that is whether text ends in an odd number of backslashes
+func isEscaped(text string) bool {
+	n := 0
+	for n < len(text) && text[len(text)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+// IsRedundant reports whether p can be left out without changing which
+// paths are ignored, given the patterns that come before and after the
+// place it would be written. The last matching pattern decides for a
+// path, so p is redundant when an equivalent pattern follows it, or when
+// one precedes it with no pattern of the other polarity in between.
+func IsRedundant(p Pattern, before, after []Pattern) bool {
+	key := p.Key()
+	for _, other := range after {
+		if other.Key() == key {
+			return true
+		}
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		if before[i].Key() == key {
+			return true
+		}
+		if before[i].Negate != p.Negate {
+			return false
+		}
+	}
+	return false
+}
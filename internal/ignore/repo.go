@@ -0,0 +1,101 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned when no git repository encloses a directory
+var ErrNotRepository = errors.New("not inside a git repository")
+
+// FindRoot walks up from dir to the root of the enclosing git repository
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+// RepositoryFiles reads every exclude file that can decide for the path,
+// given relative to the repository root, ordered as NewMatcher expects:
+// the global excludes file, .git/info/exclude, the root .gitignore and
+// the .gitignore files of the path's parent directories. Files that do
+// not exist are skipped.
+func RepositoryFiles(root, name string) ([]*File, error) {
+	var files []*File
+	add := func(filePath, dir string) error {
+		file, err := ReadFile(filePath, dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		files = append(files, file)
+		return nil
+	}
+
+	if global := GlobalExcludesFile(); global != "" {
+		if err := add(global, ""); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(filepath.Join(root, ".git", "info", "exclude"), ""); err != nil {
+		return nil, err
+	}
+
+	dir := ""
+	parts := strings.Split(strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/"), "/")
+	for i := 0; i < len(parts); i++ {
+		if err := add(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"), dir); err != nil {
+			return nil, err
+		}
+		dir = path.Join(dir, parts[i])
+	}
+	return files, nil
+}
+
+// GlobalExcludesFile returns the path of the user's global excludes
+// file, as set by core.excludesFile or git's default location
+func GlobalExcludesFile() string {
+	out, err := exec.Command("git", "config", "--path", "--get", "core.excludesFile").Output()
+	if err == nil && strings.TrimSpace(string(out)) != "" {
+		return strings.TrimSpace(string(out))
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
+
+// RelativePath returns target relative to root with '/' separators and
+// fails when target lies outside of root
+func RelativePath(root, target string) (string, error) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is outside of the repository at %s", target, root)
+	}
+	return rel, nil
+}
@@ -0,0 +1,208 @@
+package ignore
+
+import "strings"
+
+// results of matching a glob, following git's wildmatch. The abort
+// results stop the backtracking of '*' early once no match is possible.
+const (
+	wmMatch = iota
+	wmNoMatch
+	wmAbortAll
+	wmAbortToStarStar
+)
+
+// wildmatch reports whether text matches the gitignore glob. Wildcards
+// never match a '/', except for "**" when it makes up a whole path
+// component.
+func wildmatch(glob, text string) bool {
+	return doWild(glob, 0, text) == wmMatch
+}
+
+func doWild(glob string, pi int, text string) int {
+	ti := 0
+	for ; pi < len(glob); pi++ {
+		pc := glob[pi]
+		if ti >= len(text) && pc != '*' {
+			return wmAbortAll
+		}
+		switch pc {
+		case '\\':
+			pi++
+			if pi >= len(glob) || text[ti] != glob[pi] {
+				return wmNoMatch
+			}
+		case '?':
+			if text[ti] == '/' {
+				return wmNoMatch
+			}
+		case '*':
+			start := pi
+			for pi+1 < len(glob) && glob[pi+1] == '*' {
+				pi++
+			}
+			matchSlash := false
+			if pi > start {
+				// "**" only matches across directories when it is a
+				// whole path component
+				next := pi + 1
+				if (start == 0 || glob[start-1] == '/') &&
+					(next == len(glob) || glob[next] == '/' ||
+						(glob[next] == '\\' && next+1 < len(glob) && glob[next+1] == '/')) {
+					if next < len(glob) && glob[next] == '/' && doWild(glob, next+1, text[ti:]) == wmMatch {
+						return wmMatch
+					}
+					matchSlash = true
+				}
+			}
+			pi++
+			if pi == len(glob) {
+				if !matchSlash && strings.Contains(text[ti:], "/") {
+					return wmNoMatch
+				}
+				return wmMatch
+			}
+			for ; ti < len(text); ti++ {
+				result := doWild(glob, pi, text[ti:])
+				if result != wmNoMatch {
+					if !matchSlash || result != wmAbortToStarStar {
+						return result
+					}
+				} else if !matchSlash && text[ti] == '/' {
+					return wmAbortToStarStar
+				}
+			}
+			return wmAbortAll
+		case '[':
+			next, matched, ok := matchClass(glob, pi+1, text[ti])
+			if !ok {
+				return wmAbortAll
+			}
+			if !matched || text[ti] == '/' {
+				return wmNoMatch
+			}
+			pi = next
+		default:
+			if text[ti] != pc {
+				return wmNoMatch
+			}
+		}
+		ti++
+	}
+	if ti < len(text) {
+		return wmNoMatch
+	}
+	return wmMatch
+}
+
+// matchClass matches c against the bracket expression starting at pi,
+// just after its '['. It returns the index of the closing ']', whether c
+// matched and false when the expression is never closed.
+func matchClass(glob string, pi int, c byte) (int, bool, bool) {
+	if pi >= len(glob) {
+		return pi, false, false
+	}
+	negated := false
+	if glob[pi] == '!' || glob[pi] == '^' {
+		negated = true
+		pi++
+	}
+
+	matched := false
+	var prev byte
+	for first := true; ; first = false {
+		if pi >= len(glob) {
+			return pi, false, false
+		}
+		pc := glob[pi]
+		if pc == ']' && !first {
+			break
+		}
+		switch {
+		case pc == '\\':
+			pi++
+			if pi >= len(glob) {
+				return pi, false, false
+			}
+			pc = glob[pi]
+			if c == pc {
+				matched = true
+			}
+		case pc == '-' && prev != 0 && pi+1 < len(glob) && glob[pi+1] != ']':
+			pi++
+			hi := glob[pi]
+			if hi == '\\' {
+				pi++
+				if pi >= len(glob) {
+					return pi, false, false
+				}
+				hi = glob[pi]
+			}
+			if c >= prev && c <= hi {
+				matched = true
+			}
+			pc = 0
+		case pc == '[' && pi+1 < len(glob) && glob[pi+1] == ':':
+			end := strings.Index(glob[pi+2:], ":]")
+			if end < 0 {
+				if c == '[' {
+					matched = true
+				}
+				break
+			}
+			class := glob[pi+2 : pi+2+end]
+			inClass, known := matchPosixClass(class, c)
+			if !known {
+				return pi, false, false
+			}
+			if inClass {
+				matched = true
+			}
+			pi += 2 + end + 1
+			pc = 0
+		default:
+			if c == pc {
+				matched = true
+			}
+		}
+		prev = pc
+		pi++
+	}
+	return pi, matched != negated, true
+}
+
+// matchPosixClass matches c against a character class such as [:alpha:]
+// and reports false as its second result for unknown class names
+func matchPosixClass(class string, c byte) (bool, bool) {
+	isUpper := c >= 'A' && c <= 'Z'
+	isLower := c >= 'a' && c <= 'z'
+	isDigit := c >= '0' && c <= '9'
+	isAlpha := isUpper || isLower
+	isPrint := c >= 0x20 && c < 0x7f
+	switch class {
+	case "alnum":
+		return isAlpha || isDigit, true
+	case "alpha":
+		return isAlpha, true
+	case "blank":
+		return c == ' ' || c == '\t', true
+	case "cntrl":
+		return c < 0x20 || c == 0x7f, true
+	case "digit":
+		return isDigit, true
+	case "graph":
+		return isPrint && c != ' ', true
+	case "lower":
+		return isLower, true
+	case "print":
+		return isPrint, true
+	case "punct":
+		return isPrint && c != ' ' && !isAlpha && !isDigit, true
+	case "space":
+		return c == ' ' || (c >= '\t' && c <= '\r'), true
+	case "upper":
+		return isUpper, true
+	case "xdigit":
+		return isDigit || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'), true
+	}
+	return false, false
+}
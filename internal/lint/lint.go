@@ -0,0 +1,349 @@
+package lint
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/diff"
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/ignore"
+)
+
+// Severity tells how serious an issue is
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return "info"
+}
+
+// Rule is a check with a stable ID that can be referred to from CI logs
+type Rule struct {
+	ID       string
+	Name     string
+	Severity Severity
+}
+
+var (
+	TrailingWhitespace  = Rule{"GI001", "trailing-whitespace", Warning}
+	StrayBackslash      = Rule{"GI002", "stray-backslash", Warning}
+	WindowsSeparator    = Rule{"GI003", "windows-separator", Error}
+	NeverMatches        = Rule{"GI004", "never-matches", Error}
+	IneffectiveNegation = Rule{"GI005", "ineffective-negation", Warning}
+	DuplicatePattern    = Rule{"GI006", "duplicate-pattern", Info}
+	ConflictMarker      = Rule{"GI007", "conflict-marker", Error}
+)
+
+// Issue is a problem found on a line of a gitignore file
+type Issue struct {
+	Source  string
+	Line    int
+	Rule    Rule
+	Message string
+	// Fixable is set when Fix can rewrite the line safely
+	Fixable bool
+}
+
+func (i Issue) String() string {
+	fixable := ""
+	if i.Fixable {
+		fixable = " (fixable)"
+	}
+	return fmt.Sprintf("%s:%d: %s [%s %s] %s%s", i.Source, i.Line, i.Rule.Severity, i.Rule.ID, i.Rule.Name, i.Message, fixable)
+}
+
+// Lint checks the content of the gitignore file read from source
+func Lint(source string, content []byte) []Issue {
+	var issues []Issue
+	report := func(line int, rule Rule, fixable bool, format string, a ...any) {
+		issues = append(issues, Issue{
+			Source:  source,
+			Line:    line,
+			Rule:    rule,
+			Message: fmt.Sprintf(format, a...),
+			Fixable: fixable,
+		})
+	}
+
+	file := ignore.NewFile(source, "", content)
+	matcher := ignore.NewMatcher(file)
+	var seen []ignore.Pattern
+	depth := 0
+	for i, line := range strings.Split(string(content), "\n") {
+		lineNo := i + 1
+		line = strings.TrimSuffix(line, "\r")
+		depth = conditionalDepth(depth, line)
+		if diff.IsConflictMarker(line) {
+			report(lineNo, ConflictMarker, false, "unresolved conflict marker from gogi update is read as a pattern")
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if trimmed := strings.TrimRight(line, " \t"); trimmed != line && strings.TrimSpace(line) != "" {
+			if strings.HasSuffix(line, " ") && !strings.Contains(line[len(trimmed):], "\t") {
+				if !endsEscaped(trimmed) {
+					report(lineNo, TrailingWhitespace, true, "trailing spaces are ignored by git, escape them with '\\' if they are meant to match")
+				}
+			} else {
+				report(lineNo, TrailingWhitespace, false, "trailing tab is part of the pattern")
+			}
+		}
+
+		p, ok := ignore.ParseLine(line, lineNo)
+		if !ok {
+			continue
+		}
+		checkEscapes(p, func(rule Rule, fixable bool, msg string) {
+			report(lineNo, rule, fixable, "%s", msg)
+		})
+		checkNeverMatches(p, func(msg string) {
+			report(lineNo, NeverMatches, false, "%s", msg)
+		})
+		if parent, decider := excludedParent(matcher, p); decider != nil {
+			report(lineNo, IneffectiveNegation, false,
+				"negation can never take effect because its parent directory '%s' is excluded by line %d", parent, decider.Line)
+		}
+		// a line of a conditional section is only rendered on some
+		// targets, so it neither duplicates nor is duplicated by another
+		if depth > 0 {
+			continue
+		}
+		if ignore.IsRedundant(p, seen, nil) {
+			report(lineNo, DuplicatePattern, true, "pattern '%s' is already covered by an earlier line", p.Key())
+			continue
+		}
+		seen = append(seen, p)
+	}
+	return issues
+}
+
+// Fix applies the safe rewrites: trailing spaces and stray backslashes
+// are removed and duplicate patterns are dropped. These never change
+// which paths are ignored. Duplicates inside #gogi:if sections are kept,
+// since the section is not rendered on every target. It returns the fixed
+// content and the number of lines changed or removed.
+func Fix(content []byte) ([]byte, int) {
+	lines := strings.Split(string(content), "\n")
+	fixed := make([]string, 0, len(lines))
+	changed := 0
+	var seen []ignore.Pattern
+	depth := 0
+	for i, line := range lines {
+		newLine := line
+		cr := strings.HasSuffix(newLine, "\r")
+		newLine = strings.TrimSuffix(newLine, "\r")
+		depth = conditionalDepth(depth, newLine)
+		if diff.IsConflictMarker(newLine) {
+			fixed = append(fixed, line)
+			continue
+		}
+		if !strings.HasPrefix(newLine, "#") {
+			newLine = trimTrailingSpaces(newLine)
+			newLine = removeStrayBackslashes(newLine)
+		}
+		if cr {
+			newLine += "\r"
+		}
+
+		if p, ok := ignore.ParseLine(newLine, i+1); ok && depth == 0 {
+			if ignore.IsRedundant(p, seen, nil) {
+				changed++
+				continue
+			}
+			seen = append(seen, p)
+		}
+		if newLine != line {
+			changed++
+		}
+		fixed = append(fixed, newLine)
+	}
+	return []byte(strings.Join(fixed, "\n")), changed
+}
+
+// conditionalDepth returns how many #gogi:if sections are open after
+// line, given the depth before it
+func conditionalDepth(depth int, line string) int {
+	directive, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch directive {
+	case generator.DirectivePrefix + "if":
+		return depth + 1
+	case generator.DirectivePrefix + "endif":
+		if depth > 0 {
+			return depth - 1
+		}
+	}
+	return depth
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Rule.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEscapes reports backslashes that escape nothing special or that
+// look like Windows path separators
+func checkEscapes(p ignore.Pattern, report func(Rule, bool, string)) {
+	glob := p.Glob
+	inClass := false
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '[':
+			inClass = true
+			continue
+		case ']':
+			inClass = false
+			continue
+		case '\\':
+		default:
+			continue
+		}
+		if i+1 == len(glob) {
+			report(NeverMatches, false, "pattern ending in a backslash never matches")
+			return
+		}
+		next := glob[i+1]
+		i++
+		if inClass || isSpecial(next) {
+			continue
+		}
+		if i > 1 && isNameChar(glob[i-2]) && isNameChar(next) {
+			report(WindowsSeparator, false, "'\\' is not a path separator in gitignore files, use '/'")
+			continue
+		}
+		report(StrayBackslash, true, fmt.Sprintf("backslash before '%c' has no effect", next))
+	}
+}
+
+// checkNeverMatches reports patterns no path can ever match
+func checkNeverMatches(p ignore.Pattern, report func(string)) {
+	for _, component := range strings.Split(p.Glob, "/") {
+		switch component {
+		case "":
+			report("empty path component, git paths never contain '//'")
+			return
+		case ".", "..":
+			report(fmt.Sprintf("path component '%s' never matches, git paths are normalized", component))
+			return
+		}
+	}
+	if hasUnclosedClass(p.Glob) {
+		report("unclosed '[' character class never matches")
+	}
+}
+
+// excludedParent finds a literal parent directory of a negated pattern
+// that is itself excluded, since git does not look inside excluded
+// directories and so the negation can never re-include anything
+func excludedParent(m *ignore.Matcher, p ignore.Pattern) (string, *ignore.Pattern) {
+	if !p.Negate || !p.Anchored {
+		return "", nil
+	}
+	dir := path.Dir(p.Glob)
+	if dir == "." || strings.ContainsAny(dir, "*?[\\") {
+		return "", nil
+	}
+	match := m.Match(dir, true)
+	if !match.Ignored {
+		return "", nil
+	}
+	return match.Path, match.Pattern
+}
+
+// hasUnclosedClass reports whether a '[' in the glob is never closed
+func hasUnclosedClass(glob string) bool {
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '[':
+			j := i + 1
+			if j < len(glob) && (glob[j] == '!' || glob[j] == '^') {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++
+			}
+			end := strings.IndexByte(glob[j:], ']')
+			if end < 0 {
+				return true
+			}
+			i = j + end
+		}
+	}
+	return false
+}
+
+// trimTrailingSpaces removes trailing spaces that git ignores anyway
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !endsEscaped(line[:len(line)-1]) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// removeStrayBackslashes drops backslashes that escape a character with
+// no special meaning, outside of character classes
+func removeStrayBackslashes(line string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '\\' && i+1 < len(line):
+			next := line[i+1]
+			stray := !inClass && !isSpecial(next) &&
+				!(i > 0 && isNameChar(line[i-1]) && isNameChar(next))
+			if !stray {
+				sb.WriteByte(c)
+			}
+			sb.WriteByte(next)
+			i++
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// endsEscaped reports whether text ends in an odd number of backslashes
+func endsEscaped(text string) bool {
+	n := 0
+	for n < len(text) && text[len(text)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+// isSpecial reports whether escaping c changes how a pattern is read
+func isSpecial(c byte) bool {
+	return strings.IndexByte("\\*?[]! #/", c) >= 0
+}
+
+// isNameChar reports whether c commonly appears in file names
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '.' || c == '_' || c == '-' || c == '*'
+}
@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"clean file", "# comment\n*.log\n!keep.log\nbuild/\n", nil},
+		{"trailing spaces", "*.log  \n", []string{"GI001"}},
+		{"escaped trailing space", "file\\ \n", nil},
+		{"trailing tab", "*.log\t\n", []string{"GI001"}},
+		{"stray backslash", "\\foo\n", []string{"GI002"}},
+		{"needed escapes", "\\#file\n\\!file\n\\*\n", nil},
+		{"escape inside class", "[\\a]\n", nil},
+		{"windows separator", "bin\\Debug\n", []string{"GI003"}},
+		{"trailing backslash", "foo\\\n", []string{"GI004"}},
+		{"dot component", "./build\n", []string{"GI004"}},
+		{"double slash", "a//b\n", []string{"GI004"}},
+		{"unclosed class", "*.[ch\n", []string{"GI004"}},
+		{"closing bracket first", "[]]\n", nil},
+		{"negation below excluded dir", "build/\n!build/keep.txt\n", []string{"GI005"}},
+		{"negation below re-included dir", "/foo/*\n!/foo/bar\n", nil},
+		{"duplicate pattern", "*.log\n/a/b\n*.log\na/b\n", []string{"GI006", "GI006"}},
+		{"duplicate after negation", "*.log\n!keep.log\n*.log\n", nil},
+		{"same pattern in if and else", "#gogi:if os=windows\nbin/\n#gogi:else\nbin/\n#gogi:endif\n", nil},
+		{"same pattern after a conditional", "#gogi:if os=windows\nbin/\n#gogi:endif\nbin/\n*.log\n*.log\n", []string{"GI006"}},
+		{"conflict markers", "<<<<<<< .gitignore\n*.bin\n||||||| last generated\n=======\n>>>>>>> templates\n", []string{"GI007", "GI007", "GI007", "GI007"}},
+		{"merge-like pattern", "<<<<<<<x\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range Lint("test.gitignore", []byte(tt.content)) {
+				got = append(got, issue.Rule.ID)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Lint() rules = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		expected        string
+		expectedChanged int
+	}{
+		{"nothing to fix", "*.log\n", "*.log\n", 0},
+		{"trailing spaces", "*.log  \nfile\\ \n", "*.log\nfile\\ \n", 1},
+		{"stray backslash", "\\foo\n\\#bar\n", "foo\n\\#bar\n", 1},
+		{"windows separator is left alone", "bin\\Debug\n", "bin\\Debug\n", 0},
+		{"duplicates are dropped", "*.log\n*.tmp\n*.log\n", "*.log\n*.tmp\n", 1},
+		{"conditional duplicates are kept", "#gogi:if os=windows\nbin/\n#gogi:else\nbin/\n#gogi:endif\nbin/\n",
+			"#gogi:if os=windows\nbin/\n#gogi:else\nbin/\n#gogi:endif\nbin/\n", 0},
+		{"comments are left alone", "# a \\b  \n", "# a \\b  \n", 0},
+		{"conflict markers are left alone", "=======\n*.log\n=======\n", "=======\n*.log\n=======\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Fix([]byte(tt.content))
+			if string(got) != tt.expected {
+				t.Errorf("Fix() = %q, want %q", string(got), tt.expected)
+			}
+			if changed != tt.expectedChanged {
+				t.Errorf("Expected %d changed lines but got %d", tt.expectedChanged, changed)
+			}
+		})
+	}
+}
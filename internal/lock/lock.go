@@ -0,0 +1,86 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of the project lockfile
+const FileName = ".gogi.lock"
+
+// fileVersion is the version of the lockfile format
+const fileVersion = 1
+
+// Entry pins the content of a template used by the project
+type Entry struct {
+	Name string `json:"name"`
+	// Hash is the hash of the template file, see generator.ContentHash
+	Hash string `json:"hash"`
+	// Source is the URL the template was fetched from, if any
+	Source string `json:"source,omitempty"`
+}
+
+// Lock records the templates a project's ignore file was generated from
+type Lock struct {
+	Version   int     `json:"version"`
+	Templates []Entry `json:"templates"`
+}
+
+// Path returns the path of the lockfile of the project in dir
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the lockfile at path. It returns an error satisfying
+// errors.Is(err, os.ErrNotExist) when there is none.
+func Load(path string) (*Lock, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l Lock
+	if err := json.Unmarshal(content, &l); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if l.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported %s version %d", path, l.Version)
+	}
+	return &l, nil
+}
+
+// Save writes the lockfile to path
+func (l *Lock) Save(path string) error {
+	l.Version = fileVersion
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Find returns the entry of the named template
+func (l *Lock) Find(name string) (Entry, bool) {
+	for _, entry := range l.Templates {
+		if strings.EqualFold(entry.Name, name) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Set adds the entry, replacing the entry of the same template in place
+func (l *Lock) Set(entry Entry) {
+	for i, existing := range l.Templates {
+		if strings.EqualFold(existing.Name, entry.Name) {
+			l.Templates[i] = entry
+			return
+		}
+	}
+	l.Templates = append(l.Templates, entry)
+}
@@ -0,0 +1,59 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := Path(t.TempDir())
+	if _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected os.ErrNotExist but got %v", err)
+	}
+
+	var l Lock
+	l.Set(Entry{Name: "go", Hash: "sha256:1"})
+	l.Set(Entry{Name: "node", Hash: "sha256:2", Source: "https://example.com/node.gitignore"})
+	l.Set(Entry{Name: "Go", Hash: "sha256:3"})
+	if err := l.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Templates) != 2 {
+		t.Fatalf("Expected 2 entries but got %v", loaded.Templates)
+	}
+	entry, ok := loaded.Find("go")
+	if !ok || entry.Hash != "sha256:3" {
+		t.Errorf("Expected the go entry to be replaced in place but got %v", entry)
+	}
+	if entry, _ := loaded.Find("node"); entry.Source != "https://example.com/node.gitignore" {
+		t.Errorf("Expected the source to be kept but got %v", entry)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not json", "templates: []"},
+		{"unknown version", `{"version": 2, "templates": []}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Errorf("Expected an error for %q", tt.content)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project manifest
+const FileName = ".gogi.yaml"
+
+// DefaultOS and DefaultArch are the platform a manifest without os or
+// arch is rendered for
+const (
+	DefaultOS   = "linux"
+	DefaultArch = "amd64"
+)
+
+var ErrNotFound = errors.New("no " + FileName + " found")
+
+// Manifest declares the templates a project's ignore file is built from
+type Manifest struct {
+	// Output is the path of the ignore file relative to the manifest,
+	// .gitignore by default
+	Output string `yaml:"output,omitempty"`
+	// Templates are the templates or bundles to write, in order
+	Templates []string `yaml:"templates"`
+	// Vars are the values of the template variables
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Patterns are extra patterns written below the templates
+	Patterns []string `yaml:"patterns,omitempty"`
+	// Merge leaves out duplicate patterns
+	Merge bool `yaml:"merge,omitempty"`
+	// OS and Arch pin the platform conditional sections are evaluated
+	// for, so that the file is the same on every machine. They default to
+	// DefaultOS and DefaultArch rather than the platform gogi runs on.
+	OS   string `yaml:"os,omitempty"`
+	Arch string `yaml:"arch,omitempty"`
+
+	// Dir is the directory holding the manifest
+	Dir string `yaml:"-"`
+}
+
+// Find looks for the manifest in dir and its parents and loads the first
+// one found
+func Find(dir string) (*Manifest, error) {
+	for {
+		m, err := Load(filepath.Join(dir, FileName))
+		if !errors.Is(err, os.ErrNotExist) {
+			return m, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// Load reads and validates the manifest at path
+func Load(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	m.Dir = filepath.Dir(path)
+	return m, nil
+}
+
+// Parse reads and validates a manifest
+func Parse(content []byte) (*Manifest, error) {
+	var m Manifest
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	if len(m.Templates) == 0 && len(m.Patterns) == 0 {
+		return nil, fmt.Errorf("no templates or patterns listed")
+	}
+	if m.Output == "" {
+		m.Output = ".gitignore"
+	}
+	if filepath.IsAbs(m.Output) || strings.HasPrefix(filepath.Clean(m.Output), "..") {
+		return nil, fmt.Errorf("output '%s' must be a path inside the project", m.Output)
+	}
+	for _, pattern := range m.Patterns {
+		if strings.Contains(pattern, "\n") {
+			return nil, fmt.Errorf("pattern '%s' spans several lines", pattern)
+		}
+	}
+	return &m, nil
+}
+
+// OutputPath returns the path of the ignore file the manifest describes
+func (m *Manifest) OutputPath() string {
+	return filepath.Join(m.Dir, m.Output)
+}
+
+// PatternContent returns the extra patterns as file content
+func (m *Manifest) PatternContent() []byte {
+	if len(m.Patterns) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(m.Patterns, "\n") + "\n")
+}
@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		expectedOutput string
+		expectedErr    string
+	}{
+		{"templates", "templates: [go, macos]\n", ".gitignore", ""},
+		{"everything", "output: sub/.gitignore\ntemplates:\n  - go\nvars:\n  BuildDir: out\npatterns:\n  - /data/\nmerge: true\nos: linux\n", "sub/.gitignore", ""},
+		{"patterns only", "patterns: [/data/]\n", ".gitignore", ""},
+		{"nothing listed", "output: .gitignore\n", "", "no templates or patterns listed"},
+		{"unknown field", "templates: [go]\ntemplate: node\n", "", "field template not found"},
+		{"absolute output", "output: /etc/gitignore\ntemplates: [go]\n", "", "must be a path inside the project"},
+		{"output outside", "output: ../.gitignore\ntemplates: [go]\n", "", "must be a path inside the project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(tt.content))
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q but got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if m.Output != tt.expectedOutput {
+				t.Errorf("Expected output %s but got %s", tt.expectedOutput, m.Output)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if _, err := Find(sub); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound but got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("templates: [go]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	m, err := Find(sub)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if m.OutputPath() != filepath.Join(root, ".gitignore") {
+		t.Errorf("Expected output next to the manifest but got %s", m.OutputPath())
+	}
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a registry served by gogi serve
+type Client struct {
+	HTTP    *http.Client
+	BaseURL string
+}
+
+// NewClient creates a client for the registry at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// TemplateURL returns the URL the named template is fetched from
+func (c *Client) TemplateURL(name string) string {
+	return c.BaseURL + "/templates/" + url.PathEscape(name)
+}
+
+// List returns the templates in the registry
+func (c *Client) List() ([]Info, error) {
+	body, err := c.get(c.BaseURL + "/templates")
+	if err != nil {
+		return nil, err
+	}
+	var infos []Info
+	if err := json.Unmarshal(body, &infos); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %w", c.BaseURL, err)
+	}
+	return infos, nil
+}
+
+// Get fetches the content of the named template
+func (c *Client) Get(name string) ([]byte, error) {
+	return c.get(c.TemplateURL(name))
+}
+
+func (c *Client) get(target string) ([]byte, error) {
+	resp, err := c.HTTP.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response from %s: %w", target, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, target)
+	}
+	return nil, fmt.Errorf("registry returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
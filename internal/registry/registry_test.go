@@ -0,0 +1,263 @@
+package registry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, *DirStore) {
+	t.Helper()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() error = %v", err)
+	}
+	if err := store.Put("go", []byte("*.exe\n")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	server := httptest.NewServer(NewServer(store, token))
+	t.Cleanup(server.Close)
+	return server, store
+}
+
+func TestServer(t *testing.T) {
+	server, _ := newTestServer(t, "secret")
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"list templates", http.MethodGet, "/templates", "", "", http.StatusOK, `[{"name":"go","size":6,`},
+		{"get template", http.MethodGet, "/templates/go", "", "", http.StatusOK, "*.exe\n"},
+		{"get missing template", http.MethodGet, "/templates/node", "", "", http.StatusNotFound, ""},
+		{"get invalid name", http.MethodGet, "/templates/..%2Fconfig", "", "", http.StatusBadRequest, ""},
+		{"publish without token", http.MethodPut, "/templates/node", "node_modules/\n", "", http.StatusUnauthorized, ""},
+		{"publish new template", http.MethodPut, "/templates/node", "node_modules/\n", "secret", http.StatusCreated, ""},
+		{"publish existing template", http.MethodPut, "/templates/node", "dist/\n", "secret", http.StatusNoContent, ""},
+		{"get published template", http.MethodGet, "/templates/node", "", "", http.StatusOK, "dist/\n"},
+		{"delete without token", http.MethodDelete, "/templates/node", "", "wrong", http.StatusUnauthorized, ""},
+		{"delete template", http.MethodDelete, "/templates/node", "", "secret", http.StatusNoContent, ""},
+		{"delete missing template", http.MethodDelete, "/templates/node", "", "secret", http.StatusNotFound, ""},
+		{"wrong method", http.MethodPost, "/templates", "", "", http.StatusMethodNotAllowed, ""},
+		{"unknown path", http.MethodGet, "/other", "", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d but got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+			if !strings.HasPrefix(string(body), tt.expectedBody) {
+				t.Errorf("Expected body starting with %q but got %q", tt.expectedBody, string(body))
+			}
+			if strings.Contains(string(body), "hunter2") {
+				t.Errorf("Expected the environment of the server to stay out of the response but got %q", string(body))
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	server, store := newTestServer(t, "")
+	if err := store.Put("macos", []byte(".DS_Store\n*.exe\n")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	for name, content := range map[string]string{
+		"os":   "#gogi:if os=windows\nThumbs.db\n#gogi:else\n.directory\n#gogi:endif\n",
+		"inc":  "#gogi:include os\nbin/\n",
+		"vars": "{{ .BuildDir }}/\n",
+		"env":  "{{ .Secret }}\n",
+		"ci":   "#gogi:if env=GOGI_SECRET\nci/\n#gogi:endif\n",
+		"loop": "{{ range .Items }}x{{ end }}\n",
+		"call": "{{ printf \"%0999999999d\" 1 }}\n",
+	} {
+		if err := store.Put(name, []byte(content)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		accept         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"plain text", "/render?t=go,macos", "", http.StatusOK,
+			"# >>> gogi:go\n*.exe\n# <<< gogi:go\n\n# >>> gogi:macos\n.DS_Store\n*.exe\n# <<< gogi:macos\n"},
+		{"merge duplicates", "/render?t=go,macos&merge=true", "", http.StatusOK,
+			"# >>> gogi:go\n*.exe\n# <<< gogi:go\n\n# >>> gogi:macos\n.DS_Store\n# <<< gogi:macos\n"},
+		{"json format", "/render?t=go&format=json", "", http.StatusOK, `{"templates":["go"],"hash":`},
+		{"json accept header", "/render?t=go", "application/json", http.StatusOK, `{"templates":["go"],"hash":`},
+		{"unknown templates", "/render?t=go,node,rust", "", http.StatusNotFound, "unknown templates: node, rust"},
+		{"unknown templates json", "/render?t=node&format=json", "", http.StatusNotFound,
+			`{"error":"unknown templates: node","unknown":["node"]}`},
+		{"conditions for os", "/render?t=os&os=windows", "", http.StatusOK, "# >>> gogi:os\nThumbs.db\n# <<< gogi:os\n"},
+		{"conditions for other os", "/render?t=os&os=linux", "", http.StatusOK, "# >>> gogi:os\n.directory\n# <<< gogi:os\n"},
+		{"includes", "/render?t=inc&os=linux", "", http.StatusOK, "# >>> gogi:inc\n.directory\nbin/\n# <<< gogi:inc\n"},
+		{"variables", "/render?t=vars&set=BuildDir=out", "", http.StatusOK, "# >>> gogi:vars\nout/\n# <<< gogi:vars\n"},
+		{"missing variable", "/render?t=vars", "", http.StatusUnprocessableEntity, "template 'vars' line 1: no value for BuildDir"},
+		{"no templates", "/render", "", http.StatusBadRequest, ""},
+		{"environment variable not read", "/render?t=env", "", http.StatusUnprocessableEntity, "template 'env' line 1: no value for Secret"},
+		{"env condition rejected", "/render?t=ci", "", http.StatusUnprocessableEntity, "template 'ci' line 1: condition 'env=GOGI_SECRET' depends on the environment"},
+		{"range rejected", "/render?t=loop&set=Items=abc", "", http.StatusUnprocessableEntity, "could not render template 'loop': range is not allowed"},
+		{"function rejected", "/render?t=call", "", http.StatusUnprocessableEntity, "could not render template 'call': function printf is not allowed"},
+	}
+	t.Setenv("GOGI_SECRET", "hunter2")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d but got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+			if !strings.HasPrefix(string(body), tt.expectedBody) {
+				t.Errorf("Expected body starting with %q but got %q", tt.expectedBody, string(body))
+			}
+			if strings.Contains(string(body), "hunter2") {
+				t.Errorf("Expected the environment of the server to stay out of the response but got %q", string(body))
+			}
+		})
+	}
+
+	t.Run("etag revalidation", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/render?t=go")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+		if etag == "" || resp.Header.Get("Cache-Control") == "" {
+			t.Fatalf("Expected ETag and Cache-Control headers but got %v", resp.Header)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/render?t=go", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("Expected status %d but got %d", http.StatusNotModified, resp.StatusCode)
+		}
+
+		if err := store.Put("go", []byte("*.exe\n*.test\n")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+			t.Errorf("Expected a fresh render after the template changed but got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("etag follows included templates", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/render?t=inc")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+
+		if err := store.Put("os", []byte("*.swp\n")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		resp, err = http.Get(server.URL + "/render?t=inc")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("ETag") == etag {
+			t.Errorf("Expected the ETag to change with an included template")
+		}
+	})
+}
+
+func TestClient(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	client := NewClient(server.URL + "/")
+
+	infos, err := client.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "go" || infos[0].SHA256 != Hash([]byte("*.exe\n")) {
+		t.Errorf("Unexpected templates %+v", infos)
+	}
+
+	content, err := client.Get("go")
+	if err != nil || string(content) != "*.exe\n" {
+		t.Errorf("Get() = %q, %v", content, err)
+	}
+	if _, err := client.Get("node"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got %v", err)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"go", true},
+		{"visual-studio.2022", true},
+		{"", false},
+		{"../config", false},
+		{"a/b", false},
+		{".hidden", false},
+		{"Go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidName(tt.name); got != tt.expected {
+				t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
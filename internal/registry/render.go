@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/SQUASHD/gogi/internal/generator"
+	"github.com/SQUASHD/gogi/internal/structs"
+)
+
+// renderMaxAge is how long clients may cache a rendered file before
+// revalidating it with its ETag
+const renderMaxAge = "public, max-age=300"
+
+// renderResponse is the JSON form of a rendered .gitignore file
+type renderResponse struct {
+	Templates []string `json:"templates"`
+	Hash      string   `json:"hash"`
+	Content   string   `json:"content"`
+	Skipped   int      `json:"skipped,omitempty"`
+}
+
+// unknownResponse is the JSON body returned for unknown template names
+type unknownResponse struct {
+	Error   string   `json:"error"`
+	Unknown []string `json:"unknown"`
+}
+
+// handleRender composes the templates named in the t query parameter,
+// for example /render?t=go,macos,jetbrains. It answers with plain text,
+// or JSON when format=json is given or JSON is accepted, and adds
+// merge=true to leave out duplicate patterns. The templates are rendered
+// like gogi generate does: variables come from set=key=value parameters,
+// #gogi:if directives are evaluated for the os and arch parameters, which
+// default to the platform of the server, and includes are resolved from
+// the store. The environment of the server is never read, env= conditions
+// are rejected and placeholders are limited to fields and if actions.
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var names []string
+	for _, param := range query["t"] {
+		for _, name := range strings.Split(param, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	asJSON := query.Get("format") == "json" ||
+		(query.Get("format") == "" && strings.Contains(r.Header.Get("Accept"), "application/json"))
+	if len(names) == 0 {
+		http.Error(w, "no templates given, use /render?t=name,name", http.StatusBadRequest)
+		return
+	}
+
+	// published templates are not trusted: they must not read the
+	// environment of the server or render without bound
+	target := generator.Target{OS: query.Get("os"), Arch: query.Get("arch"), NoEnv: true}
+	sections := make([]generator.Section, 0, len(names))
+	var unknown []string
+	for _, name := range names {
+		content, err := s.Store.Get(name)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidName) {
+			unknown = append(unknown, name)
+			continue
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		sections = append(sections, generator.Section{Name: name, Content: content})
+	}
+	if len(unknown) > 0 {
+		msg := "unknown templates: " + strings.Join(unknown, ", ")
+		if asJSON {
+			writeJSON(w, http.StatusNotFound, unknownResponse{Error: msg, Unknown: unknown})
+			return
+		}
+		http.Error(w, msg, http.StatusNotFound)
+		return
+	}
+
+	merge := query.Get("merge") == "true" || query.Get("merge") == "1"
+	vars := make(map[string]string)
+	for _, pair := range query["set"] {
+		if name, value, ok := strings.Cut(pair, "="); ok && name != "" {
+			vars[name] = value
+		}
+	}
+
+	// the rendered file also depends on the templates pulled in with
+	// #gogi:include and #gogi:extends, so they are part of the ETag
+	loaded := append([]generator.Section{}, sections...)
+	opts := generator.Options{
+		Merge:      merge,
+		Vars:       vars,
+		Target:     target,
+		Restricted: true,
+		Load: func(name string) (structs.Template, []byte, error) {
+			content, err := s.Store.Get(strings.ToLower(name))
+			if err != nil {
+				return structs.Template{}, nil, err
+			}
+			loaded = append(loaded, generator.Section{Name: name, Content: content})
+			return structs.Template{Name: strings.ToLower(name)}, content, nil
+		},
+	}
+	rendered := make([]generator.Section, len(sections))
+	for i, section := range sections {
+		content, err := generator.RenderTemplate(structs.Template{Name: section.Name}, section.Content, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		rendered[i] = generator.Section{Name: section.Name, Content: content}
+	}
+
+	etag := renderETag(loaded, query, asJSON)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", renderMaxAge)
+	w.Header().Set("Vary", "Accept")
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, result, err := generator.Compose(rendered, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if asJSON {
+		writeJSON(w, http.StatusOK, renderResponse{
+			Templates: names,
+			Hash:      Hash(content),
+			Content:   string(content),
+			Skipped:   result.Skipped,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(content)
+}
+
+// renderETag keys a rendered file on the names and content hashes of the
+// templates it was rendered from along with the query it was rendered for
+func renderETag(templates []generator.Section, query url.Values, asJSON bool) string {
+	var sb strings.Builder
+	for _, templ := range templates {
+		sb.WriteString(templ.Name + ":" + Hash(templ.Content) + "\n")
+	}
+	for _, param := range []string{"merge", "os", "arch"} {
+		sb.WriteString(param + "=" + query.Get(param) + "\n")
+	}
+	for _, pair := range query["set"] {
+		sb.WriteString("set=" + pair + "\n")
+	}
+	if asJSON {
+		sb.WriteString("json\n")
+	}
+	return `"` + Hash([]byte(sb.String())) + `"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxTemplateSize limits the size of published templates
+const maxTemplateSize = 1 << 20
+
+// Server exposes a Store over HTTP:
+//
+//	GET    /templates         list the templates as JSON
+//	GET    /templates/{name}  fetch a template
+//	PUT    /templates/{name}  publish a template
+//	DELETE /templates/{name}  delete a template
+//	GET    /render?t=a,b      compose templates into a .gitignore file
+//
+// When Token is set, publishing and deleting require it as a bearer token.
+type Server struct {
+	Store Store
+	Token string
+}
+
+// NewServer creates a server for the store
+func NewServer(store Store, token string) *Server {
+	return &Server{Store: store, Token: token}
+}
+
+// ServeHTTP routes the registry API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/templates" || r.URL.Path == "/templates/":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.handleList(w)
+	case r.URL.Path == "/render":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.handleRender(w, r)
+	case strings.HasPrefix(r.URL.Path, "/templates/"):
+		name := strings.TrimPrefix(r.URL.Path, "/templates/")
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			s.handleGet(w, name)
+		case http.MethodPut:
+			if s.authorized(w, r) {
+				s.handlePut(w, r, name)
+			}
+		case http.MethodDelete:
+			if s.authorized(w, r) {
+				s.handleDelete(w, name)
+			}
+		default:
+			methodNotAllowed(w, "GET, PUT, DELETE")
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter) {
+	infos, err := s.Store.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, name string) {
+	content, err := s.Store.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("ETag", `"`+Hash(content)+`"`)
+	w.Write(content)
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request, name string) {
+	content, err := io.ReadAll(io.LimitReader(r.Body, maxTemplateSize+1))
+	if err != nil {
+		http.Error(w, "unable to read template", http.StatusBadRequest)
+		return
+	}
+	if len(content) > maxTemplateSize {
+		http.Error(w, "template is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	_, getErr := s.Store.Get(name)
+	if err := s.Store.Put(name, content); err != nil {
+		writeError(w, err)
+		return
+	}
+	if errors.Is(getErr, ErrNotFound) {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, name string) {
+	if err := s.Store.Delete(name); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized checks the bearer token for requests that change the store
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	got := []byte(r.Header.Get("Authorization"))
+	if s.Token == "" || subtle.ConstantTimeCompare(got, []byte("Bearer "+s.Token)) == 1 {
+		return true
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// writeError maps store errors onto HTTP status codes
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for templates missing from the store
+	ErrNotFound = errors.New("template not found")
+	// ErrInvalidName is returned for names that can not be stored
+	ErrInvalidName = errors.New("invalid template name")
+)
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Info describes a template in the store
+type Info struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Store holds the templates served by the registry
+type Store interface {
+	List() ([]Info, error)
+	Get(name string) ([]byte, error)
+	Put(name string, content []byte) error
+	Delete(name string) error
+}
+
+// DirStore is a Store keeping every template as <name>.gitignore in a directory
+type DirStore struct {
+	Dir string
+}
+
+// NewDirStore creates a store in dir, creating the directory if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create template store at %s: %w", dir, err)
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+// ValidName reports whether name can be used for a stored template
+func ValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+func (s *DirStore) path(name string) (string, error) {
+	if !ValidName(name) {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidName, name)
+	}
+	return filepath.Join(s.Dir, name+".gitignore"), nil
+}
+
+// List returns every template in the store sorted by name
+func (s *DirStore) List() ([]Info, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template store: %w", err)
+	}
+	infos := []Info{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gitignore")
+		if entry.IsDir() || name == entry.Name() || !ValidName(name) {
+			continue
+		}
+		content, err := s.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, Info{Name: name, Size: int64(len(content)), SHA256: Hash(content)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Get returns the content of the named template
+func (s *DirStore) Get(name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, name)
+	}
+	return content, err
+}
+
+// Put creates or replaces the named template
+func (s *DirStore) Put(name string, content []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// Delete removes the named template
+func (s *DirStore) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: '%s'", ErrNotFound, name)
+	}
+	return err
+}
+
+// Hash returns the hex encoded SHA-256 of content
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
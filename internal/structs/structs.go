@@ -3,22 +3,77 @@ package structs
 import "github.com/SQUASHD/go-config/config"
 
 type TemplateConfig struct {
-	Editor          string     `json:"editor"`
-	Base            string     `json:"base"`
-	DefaultOverride bool       `json:"default_override"`
-	Templates       []Template `json:"templates"`
+	Editor          string       `json:"editor"`
+	Base            string       `json:"base"`
+	BaseRules       []BaseRule   `json:"base_rules"`
+	DefaultOverride bool         `json:"default_override"`
+	Header          bool         `json:"header"`
+	Templates       []Template   `json:"templates"`
+	Bundles         []Bundle     `json:"bundles"`
+	Sources         []Source     `json:"sources"`
+	Git             GitSync      `json:"git"`
+	Remotes         []Remote     `json:"remotes"`
+	Detect          []DetectRule `json:"detect"`
 }
 
 type Template struct {
 	Name string `json:"name"`
 	Path string `json:"path"`
+	// Source is the URL the template was fetched from, if any
+	Source string `json:"source,omitempty"`
+	// Vars are the default values of the variables the template uses
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Bundle is a named, ordered list of templates that are used together
+type Bundle struct {
+	Name      string   `json:"name"`
+	Templates []string `json:"templates"`
+}
+
+// Source is an HTTP server serving templates as <url>/<name>.gitignore
+type Source struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Remote is a template registry served by gogi serve
+type Remote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// BaseRule sets the base template for the directories matching Path,
+// which is a path prefix or a glob such as ~/work/*
+type BaseRule struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+}
+
+// DetectRule suggests templates for projects containing a file matching
+// Marker, which is a file name or a glob such as *.csproj
+type DetectRule struct {
+	Marker    string   `json:"marker"`
+	Templates []string `json:"templates"`
+}
+
+// GitSync configures the template directory as a git repository that is
+// kept in sync with a remote
+type GitSync struct {
+	Remote string `json:"remote"`
+	Branch string `json:"branch"`
 }
 
 func (c TemplateConfig) Default() config.Config {
 	return TemplateConfig{
 		Editor:          "code",
 		Base:            "",
+		BaseRules:       []BaseRule{},
 		DefaultOverride: false,
 		Templates:       []Template{},
+		Bundles:         []Bundle{},
+		Sources:         []Source{},
+		Remotes:         []Remote{},
+		Detect:          []DetectRule{},
 	}
 }
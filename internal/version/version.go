@@ -0,0 +1,19 @@
+package version
+
+import "runtime/debug"
+
+// Version is the gogi version. Release builds can set it with
+// -ldflags "-X github.com/SQUASHD/gogi/internal/version.Version=v1.2.3",
+// otherwise it is read from the module version of the build.
+var Version = ""
+
+// String returns the version of this gogi build
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
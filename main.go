@@ -49,10 +49,12 @@ func main() {
 	ctx.HandleCommand(args)
 }
 
+// sanitizeArgs lowercases the command name. The remaining arguments are
+// kept as given since some of them are file paths.
 func sanitizeArgs(args []string) []string {
-	sanitizedArgs := []string{}
-	for _, arg := range args {
-		sanitizedArgs = append(sanitizedArgs, strings.ToLower(arg))
+	sanitizedArgs := append([]string{}, args...)
+	if len(sanitizedArgs) > 1 {
+		sanitizedArgs[1] = strings.ToLower(sanitizedArgs[1])
 	}
 	return sanitizedArgs
 }